@@ -0,0 +1,53 @@
+// Command mockcdn serves mock ArvanCloud and VergeCloud management APIs
+// generated from the bundled OpenAPI specs, under /arvan and /verge.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers/mock"
+)
+
+func main() {
+	cfg := config.Load()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	for _, id := range cfg.ProviderIDs() {
+		provider := cfg.Providers[id]
+		doc, err := openapi.Load(provider.SpecPath)
+		if err != nil {
+			log.Fatalf("%s: %v", id, err)
+		}
+
+		opts := mock.ProviderOptions(provider)
+		opts.Latency, _ = time.ParseDuration(os.Getenv("MOCK_LATENCY"))
+		opts.ErrorRate, _ = strconv.ParseFloat(os.Getenv("MOCK_ERROR_RATE"), 64)
+		opts.ErrorStatus, _ = strconv.Atoi(os.Getenv("MOCK_ERROR_STATUS"))
+		opts.RateLimit, _ = strconv.ParseFloat(os.Getenv("MOCK_RPS"), 64)
+		opts.RateBurst, _ = strconv.Atoi(os.Getenv("MOCK_BURST"))
+		opts.Seed, _ = strconv.ParseInt(os.Getenv("MOCK_SEED"), 10, 64)
+
+		prefix := "/" + id
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, mock.New(doc, opts)))
+		log.Printf("mock %s API at %s (%d paths)", provider.Name, prefix, len(doc.Paths))
+	}
+
+	port := os.Getenv("MOCK_PORT")
+	if port == "" {
+		port = "9090"
+	}
+
+	log.Printf("mock CDN API listening on :%s", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		log.Fatalf("server stopped: %v", err)
+	}
+}
@@ -7,12 +7,17 @@ import (
 
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/redact"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/server"
 )
 
 func main() {
 	cfg := config.Load()
+	log.SetOutput(redact.New(cfg.Secrets()...).Writer(os.Stderr))
 	registry := providers.NewRegistry(cfg)
+	if err := registry.LoadSpecs(); err != nil {
+		log.Printf("openapi specs not loaded: %v", err)
+	}
 	s := server.New(cfg, registry)
 
 	port := os.Getenv("PORT")
@@ -1,3 +1,8 @@
 module github.com/mehrdad/project/training/cloud/Test-CDN/backend
 
 go 1.21
+
+require (
+	golang.org/x/net v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type ProviderConfig struct {
@@ -13,11 +18,142 @@ type ProviderConfig struct {
 	APIBase   string
 	Domain    string
 	Headers   map[string]string
+
+	// AuthHeader names the entry of Headers that carries the API
+	// credential, sent as AuthScheme followed by the token. RevokedToken is
+	// an expired or revoked credential for the auth suite; when empty a
+	// placeholder is sent instead.
+	AuthHeader   string
+	AuthScheme   string
+	RevokedToken string
+
+	// Domains are named zones selectable per call; Domain, OriginURL and
+	// Hosts above are the default. Profiles maps credential profile names
+	// to tokens, sent in AuthHeader in place of the default token.
+	Domains  []DomainConfig
+	Profiles map[string]string
+
+	// OriginAddr is the address ("ip" or "ip:port") of the origin behind
+	// the CDN, used to probe it directly. OriginHost overrides the Host
+	// header and TLS server name sent there; it defaults to the host of
+	// OriginURL.
+	OriginAddr string
+	OriginHost string
+
+	// ErrorPages maps a status code to a marker string expected in the body
+	// of the custom error page configured on the CDN for that status.
+	ErrorPages map[int]string
+
+	// Timeout bounds each management API call; ResourceTimeouts overrides
+	// it per resource (e.g. "analytics"). Zero means the registry default.
+	Timeout          time.Duration
+	ResourceTimeouts map[string]time.Duration
+
+	// RateLimit caps management API calls per second, with bursts of up to
+	// RateBurst; zero disables client-side limiting.
+	RateLimit float64
+	RateBurst int
+
+	// SpecPath is the provider's bundled OpenAPI document, used to build
+	// the operation catalog.
+	SpecPath string
+}
+
+// DomainConfig is one zone managed through a provider. OriginURL and Hosts
+// fall back to the provider's when empty.
+type DomainConfig struct {
+	Name      string
+	Domain    string
+	OriginURL string
+	Hosts     []string
+}
+
+// Scoped returns p with the named domain and credential profile applied.
+// Empty names keep the defaults; a domain can also be given by its domain
+// name.
+func (p ProviderConfig) Scoped(domain, profile string) (ProviderConfig, error) {
+	if domain = strings.TrimSpace(domain); domain != "" && domain != p.Domain {
+		d, ok := p.domainByName(domain)
+		if !ok {
+			return p, fmt.Errorf("provider %s has no domain %q", p.ID, domain)
+		}
+		p.Domain = d.Domain
+		if d.OriginURL != "" {
+			p.OriginURL = d.OriginURL
+		}
+		if len(d.Hosts) > 0 {
+			p.Hosts = d.Hosts
+		}
+	}
+	if profile = strings.TrimSpace(profile); profile != "" && profile != DefaultProfile {
+		token, ok := p.Profiles[profile]
+		if !ok {
+			return p, fmt.Errorf("provider %s has no credential profile %q", p.ID, profile)
+		}
+		if p.AuthHeader == "" {
+			return p, fmt.Errorf("provider %s has no auth header for profiles", p.ID)
+		}
+		headers := make(map[string]string, len(p.Headers))
+		for k, v := range p.Headers {
+			headers[k] = v
+		}
+		headers[p.AuthHeader] = p.AuthScheme + token
+		p.Headers = headers
+	}
+	return p, nil
 }
 
+func (p ProviderConfig) domainByName(name string) (DomainConfig, bool) {
+	for _, d := range p.Domains {
+		if d.Name == name || d.Domain == name {
+			return d, true
+		}
+	}
+	return DomainConfig{}, false
+}
+
+// DefaultProfile names the credentials configured by the provider's token
+// variable.
+const DefaultProfile = "default"
+
 type Config struct {
 	Providers map[string]ProviderConfig
-	ordered   []string
+	// Resolvers are the DNS servers ("ip" or "ip:port") used by the DNS
+	// diagnostics suite.
+	Resolvers []string
+	// WriteConfirmToken must accompany destructive (anything but GET/HEAD)
+	// API calls made through /api-test, and purges with a non-default
+	// profile. When empty, those calls are refused.
+	WriteConfirmToken string
+	// APIMaxAttempts caps attempts per provider API call, including the
+	// first; zero keeps the registry default.
+	APIMaxAttempts int
+	// APIFixtureMode is "record" to save provider API calls to fixture
+	// files under APIFixtureDir, or "replay" to answer calls from them
+	// without network access. Empty disables fixtures.
+	APIFixtureMode string
+	APIFixtureDir  string
+	ordered        []string
+	// external holds credentials read for code outside this package, such
+	// as the Cloudflare purge token, so Secrets covers them too.
+	external []string
+}
+
+// Secrets returns every credential the configuration holds: provider
+// tokens with their auth scheme stripped, profile and revoked tokens and
+// the write confirmation token. Empty values are included.
+func (c Config) Secrets() []string {
+	out := append([]string{c.WriteConfirmToken}, c.external...)
+	for _, p := range c.Providers {
+		if p.AuthHeader != "" {
+			out = append(out, strings.TrimPrefix(p.Headers[p.AuthHeader], p.AuthScheme))
+		}
+		out = append(out, p.RevokedToken)
+		for _, token := range p.Profiles {
+			out = append(out, token)
+		}
+	}
+	return out
 }
 
 func (c Config) ProviderIDs() []string {
@@ -39,6 +175,27 @@ func (c Config) ProviderByID(id string) (ProviderConfig, bool) {
 	return p, ok
 }
 
+// Scoped returns c with the named domain and profile applied to the
+// providers in ids. Every one of them must define the names.
+func (c Config) Scoped(ids []string, domain, profile string) (Config, error) {
+	if strings.TrimSpace(domain) == "" && strings.TrimSpace(profile) == "" {
+		return c, nil
+	}
+	providers := make(map[string]ProviderConfig, len(c.Providers))
+	for id, p := range c.Providers {
+		providers[id] = p
+	}
+	for _, id := range ids {
+		scoped, err := providers[id].Scoped(domain, profile)
+		if err != nil {
+			return c, err
+		}
+		providers[id] = scoped
+	}
+	c.Providers = providers
+	return c, nil
+}
+
 func (c Config) DefaultProviderID() string {
 	if len(c.ordered) > 0 {
 		return c.ordered[0]
@@ -50,28 +207,57 @@ func (c Config) DefaultProviderID() string {
 }
 
 func Load() Config {
+	// OPENAPI_DIR holds the bundled provider specs; the default suits running
+	// from backend/ inside the repository.
+	specDir := envOr("OPENAPI_DIR", "../api")
+
 	providers := map[string]ProviderConfig{
 		"verge": {
-			ID:        "verge",
-			Name:      "VergeCloud",
-			OriginURL: envOr("VERGE_ORIGIN_URL", "https://test-verge-test.shop"),
-			Hosts:     []string{"test-verge-test.shop", "www.test-verge-test.shop"},
-			APIBase:   trim(envOr("VERGE_API_BASE", "https://api.vergecloud.com/v1")),
-			Domain:    envOr("VERGE_DOMAIN", ""),
+			ID:               "verge",
+			Name:             "VergeCloud",
+			OriginURL:        envOr("VERGE_ORIGIN_URL", "https://test-verge-test.shop"),
+			Hosts:            []string{"test-verge-test.shop", "www.test-verge-test.shop"},
+			APIBase:          trim(envOr("VERGE_API_BASE", "https://api.vergecloud.com/v1")),
+			Domain:           envOr("VERGE_DOMAIN", ""),
+			OriginAddr:       envOr("VERGE_ORIGIN_ADDR", ""),
+			OriginHost:       envOr("VERGE_ORIGIN_HOST", ""),
+			ErrorPages:       parseErrorPages(envOr("VERGE_ERROR_PAGES", "")),
+			Timeout:          parseDuration(envOr("VERGE_API_TIMEOUT", "")),
+			ResourceTimeouts: parseDurations(envOr("VERGE_API_RESOURCE_TIMEOUTS", "")),
+			RateLimit:        parseFloat(envOr("VERGE_API_RPS", "")),
+			RateBurst:        atoiOr(envOr("VERGE_API_BURST", ""), 0),
+			SpecPath:         envOr("VERGE_OPENAPI_SPEC", filepath.Join(specDir, "vergecloud-api.json")),
+			AuthHeader:       "X-API-Key",
+			RevokedToken:     SecretEnv("VERGE_REVOKED_TOKEN"),
+			Domains:          parseDomains("VERGE"),
+			Profiles:         parseProfiles("VERGE"),
 			Headers: map[string]string{
-				"X-API-Key":    envOr("VERGE_TOKEN", ""),
+				"X-API-Key":    SecretEnv("VERGE_TOKEN"),
 				"Content-Type": "application/json",
 			},
 		},
 		"arvan": {
-			ID:        "arvan",
-			Name:      "ArvanCloud",
-			OriginURL: envOr("ARVAN_ORIGIN_URL", "https://test20250316.ir"),
-			Hosts:     []string{"test20250316.ir", "www.test20250316.ir"},
-			APIBase:   trim(envOr("ARVAN_API_BASE", "https://napi.arvancloud.ir/cdn/4.0")),
-			Domain:    envOr("ARVAN_DOMAIN", ""),
+			ID:               "arvan",
+			Name:             "ArvanCloud",
+			OriginURL:        envOr("ARVAN_ORIGIN_URL", "https://test20250316.ir"),
+			Hosts:            []string{"test20250316.ir", "www.test20250316.ir"},
+			APIBase:          trim(envOr("ARVAN_API_BASE", "https://napi.arvancloud.ir/cdn/4.0")),
+			Domain:           envOr("ARVAN_DOMAIN", ""),
+			OriginAddr:       envOr("ARVAN_ORIGIN_ADDR", ""),
+			OriginHost:       envOr("ARVAN_ORIGIN_HOST", ""),
+			ErrorPages:       parseErrorPages(envOr("ARVAN_ERROR_PAGES", "")),
+			Timeout:          parseDuration(envOr("ARVAN_API_TIMEOUT", "")),
+			ResourceTimeouts: parseDurations(envOr("ARVAN_API_RESOURCE_TIMEOUTS", "")),
+			RateLimit:        parseFloat(envOr("ARVAN_API_RPS", "")),
+			RateBurst:        atoiOr(envOr("ARVAN_API_BURST", ""), 0),
+			SpecPath:         envOr("ARVAN_OPENAPI_SPEC", filepath.Join(specDir, "arvancloud-api.yml")),
+			AuthHeader:       "Authorization",
+			AuthScheme:       "apikey ",
+			RevokedToken:     SecretEnv("ARVAN_REVOKED_TOKEN"),
+			Domains:          parseDomains("ARVAN"),
+			Profiles:         parseProfiles("ARVAN"),
 			Headers: map[string]string{
-				"Authorization": "apikey " + envOr("ARVAN_TOKEN", ""),
+				"Authorization": "apikey " + SecretEnv("ARVAN_TOKEN"),
 				"Content-Type":  "application/json",
 			},
 		},
@@ -80,11 +266,36 @@ func Load() Config {
 	order := []string{"verge", "arvan"}
 
 	return Config{
-		Providers: providers,
-		ordered:   order,
+		Providers:         providers,
+		Resolvers:         splitList(envOr("DNS_RESOLVERS", "")),
+		WriteConfirmToken: SecretEnv("API_WRITE_CONFIRM_TOKEN"),
+		APIMaxAttempts:    atoiOr(envOr("API_MAX_ATTEMPTS", ""), 0),
+		APIFixtureMode:    strings.ToLower(envOr("API_FIXTURE_MODE", "")),
+		APIFixtureDir:     envOr("API_FIXTURE_DIR", "fixtures"),
+		ordered:           order,
+		external:          []string{SecretEnv("CF_API_TOKEN")},
 	}
 }
 
+// SecretEnv reads a credential from the environment variable key or, when
+// that is unset, from the file named by key_FILE, such as a Docker secret
+// under /run/secrets. Surrounding whitespace in the file is dropped.
+func SecretEnv(key string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return ""
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("%s_FILE not read: %v", key, err)
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
+
 func envOr(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -95,3 +306,121 @@ func envOr(key, fallback string) string {
 func trim(input string) string {
 	return strings.TrimRight(strings.TrimSpace(input), "/")
 }
+
+func splitList(input string) []string {
+	var out []string
+	for _, part := range strings.Split(input, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// parseDomains reads <PREFIX>_DOMAINS ("shop=shop.example.com,...") with
+// optional per-name origins in <PREFIX>_DOMAIN_ORIGINS ("shop=https://...")
+// and hosts in <PREFIX>_DOMAIN_HOSTS ("shop=a.example.com|b.example.com").
+func parseDomains(prefix string) []DomainConfig {
+	origins := parsePairs(envOr(prefix+"_DOMAIN_ORIGINS", ""))
+	hosts := parsePairs(envOr(prefix+"_DOMAIN_HOSTS", ""))
+	var out []DomainConfig
+	for _, entry := range splitList(envOr(prefix+"_DOMAINS", "")) {
+		name, domain, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, domain = entry, entry
+		}
+		name, domain = strings.TrimSpace(name), strings.TrimSpace(domain)
+		if name == "" || domain == "" {
+			continue
+		}
+		d := DomainConfig{Name: name, Domain: domain, OriginURL: trim(origins[name])}
+		for _, host := range strings.Split(hosts[name], "|") {
+			if host = strings.TrimSpace(host); host != "" {
+				d.Hosts = append(d.Hosts, host)
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// parseProfiles reads the profile names in <PREFIX>_PROFILES and each
+// token from <PREFIX>_TOKEN_<NAME> (or its _FILE), skipping profiles
+// without a token.
+func parseProfiles(prefix string) map[string]string {
+	out := make(map[string]string)
+	for _, name := range splitList(envOr(prefix+"_PROFILES", "")) {
+		key := prefix + "_TOKEN_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+		if token := SecretEnv(key); token != "" {
+			out[name] = token
+		}
+	}
+	return out
+}
+
+func parsePairs(input string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range splitList(input) {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return out
+}
+
+// parseErrorPages reads "404=marker,503=other marker" into a status map,
+// skipping malformed entries.
+func parseErrorPages(input string) map[int]string {
+	out := make(map[int]string)
+	for _, entry := range splitList(input) {
+		code, marker, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		status, err := strconv.Atoi(strings.TrimSpace(code))
+		if err != nil || strings.TrimSpace(marker) == "" {
+			continue
+		}
+		out[status] = strings.TrimSpace(marker)
+	}
+	return out
+}
+
+func parseDuration(input string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(input))
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// parseDurations reads "analytics=60s,domains=10s" into a resource map,
+// skipping malformed entries.
+func parseDurations(input string) map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	for _, entry := range splitList(input) {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if d := parseDuration(value); d > 0 {
+			out[strings.Trim(strings.TrimSpace(key), "/")] = d
+		}
+	}
+	return out
+}
+
+func parseFloat(input string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	return f
+}
+
+func atoiOr(input string, fallback int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(input)); err == nil {
+		return n
+	}
+	return fallback
+}
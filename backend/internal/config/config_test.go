@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretEnvReadsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "arvan_token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ARVAN_TOKEN", "")
+	t.Setenv("ARVAN_TOKEN_FILE", file)
+	if got := SecretEnv("ARVAN_TOKEN"); got != "from-file" {
+		t.Fatalf("expected the file's token, got %q", got)
+	}
+	cfg := Load()
+	if cfg.Providers["arvan"].Headers["Authorization"] != "apikey from-file" {
+		t.Fatalf("unexpected auth header %q", cfg.Providers["arvan"].Headers["Authorization"])
+	}
+
+	t.Setenv("ARVAN_TOKEN", "from-env")
+	if got := SecretEnv("ARVAN_TOKEN"); got != "from-env" {
+		t.Fatalf("expected the variable to win over the file, got %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import "encoding/json"
+
+type arvanDomain struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Domain      string   `json:"domain"`
+	Status      string   `json:"status"`
+	Type        string   `json:"type"`
+	NSKeys      []string `json:"ns_keys"`
+	CNAMETarget string   `json:"cname_target"`
+	CustomCNAME string   `json:"custom_cname"`
+}
+
+type arvanCache struct {
+	CacheStatus      string `json:"cache_status"`
+	DeveloperMode    bool   `json:"cache_developer_mode"`
+	CachePage200     string `json:"cache_page_200"`
+	CacheBrowser     string `json:"cache_browser"`
+	CacheMaxSize     int64  `json:"cache_max_size"`
+	CacheArgs        bool   `json:"cache_args"`
+	ConsistentUptime bool   `json:"cache_consistent_uptime"`
+}
+
+var arvanMapper = mapper{
+	domain: func(data json.RawMessage) (Domain, error) {
+		d, err := decode[arvanDomain](data)
+		if err != nil {
+			return Domain{}, err
+		}
+		// name replaced the deprecated domain attribute.
+		name := d.Name
+		if name == "" {
+			name = d.Domain
+		}
+		target := d.CustomCNAME
+		if target == "" {
+			target = d.CNAMETarget
+		}
+		return Domain{
+			ID:          d.ID,
+			Name:        name,
+			Status:      d.Status,
+			SetupType:   d.Type,
+			NameServers: d.NSKeys,
+			CNAMETarget: target,
+		}, nil
+	},
+	dns: func(data json.RawMessage) (DNSRecord, error) {
+		r, err := decode[rawDNSRecord](data)
+		return r.normalize(), err
+	},
+	ssl: func(data json.RawMessage) (SSLSettings, error) {
+		s, err := decode[rawSSL](data)
+		return s.normalize(), err
+	},
+	cache: func(data json.RawMessage) (CacheSettings, error) {
+		c, err := decode[arvanCache](data)
+		return CacheSettings{
+			Mode:             c.CacheStatus,
+			DeveloperMode:    c.DeveloperMode,
+			EdgeTTL:          c.CachePage200,
+			BrowserTTL:       c.CacheBrowser,
+			MaxFileSize:      c.CacheMaxSize,
+			QueryStringInKey: c.CacheArgs,
+			ServeStale:       c.ConsistentUptime,
+		}, err
+	},
+	firewall: mapFirewall,
+	traffic: func(data json.RawMessage) (TrafficReport, error) {
+		t, err := decode[rawTraffic](data)
+		return t.normalize(), err
+	},
+}
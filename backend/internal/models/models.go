@@ -0,0 +1,328 @@
+// Package models defines provider-neutral shapes for CDN management API
+// resources and maps each provider's raw JSON onto them.
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Domain struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	SetupType   string   `json:"setupType,omitempty"`
+	NameServers []string `json:"nameServers,omitempty"`
+	CNAMETarget string   `json:"cnameTarget,omitempty"`
+}
+
+type DNSRecord struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl"`
+	Values  []string `json:"values"`
+	Proxied bool     `json:"proxied"`
+}
+
+type SSLSettings struct {
+	Enabled         bool   `json:"enabled"`
+	CertificateMode string `json:"certificateMode,omitempty"`
+	MinTLSVersion   string `json:"minTlsVersion,omitempty"`
+	HTTPSRedirect   bool   `json:"httpsRedirect"`
+	HSTS            bool   `json:"hsts"`
+	HSTSMaxAge      string `json:"hstsMaxAge,omitempty"`
+	HSTSSubdomains  bool   `json:"hstsSubdomains"`
+	HSTSPreload     bool   `json:"hstsPreload"`
+	HTTP3           bool   `json:"http3"`
+}
+
+type CacheSettings struct {
+	Mode             string `json:"mode"`
+	DeveloperMode    bool   `json:"developerMode"`
+	EdgeTTL          string `json:"edgeTtl,omitempty"`
+	BrowserTTL       string `json:"browserTtl,omitempty"`
+	MaxFileSize      int64  `json:"maxFileSize,omitempty"`
+	QueryStringInKey bool   `json:"queryStringInKey"`
+	ServeStale       bool   `json:"serveStale"`
+}
+
+// FirewallRule is one rule of the firewall. A provider's default action is
+// reported as a rule named "default" without an expression.
+type FirewallRule struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	Expression string `json:"expression,omitempty"`
+	Action     string `json:"action"`
+	Priority   int    `json:"priority,omitempty"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// TrafficReport summarises a traffic report: requests and bytes served,
+// and how many of them the edge served from cache.
+type TrafficReport struct {
+	Requests       int64   `json:"requests"`
+	CachedRequests int64   `json:"cachedRequests"`
+	Bytes          int64   `json:"bytes"`
+	CachedBytes    int64   `json:"cachedBytes"`
+	CacheHitRatio  float64 `json:"cacheHitRatio"`
+	PeakAt         string  `json:"peakAt,omitempty"`
+}
+
+// mapper converts the data member of one provider's responses.
+type mapper struct {
+	domain   func(json.RawMessage) (Domain, error)
+	dns      func(json.RawMessage) (DNSRecord, error)
+	ssl      func(json.RawMessage) (SSLSettings, error)
+	cache    func(json.RawMessage) (CacheSettings, error)
+	firewall func(json.RawMessage) ([]FirewallRule, error)
+	traffic  func(json.RawMessage) (TrafficReport, error)
+}
+
+var mappers = map[string]mapper{
+	"arvan": arvanMapper,
+	"verge": vergeMapper,
+}
+
+var aliases = map[string]string{
+	"arvancloud": "arvan",
+	"vergecloud": "verge",
+}
+
+// ErrUnsupported is returned for resources that have no normalised model.
+var ErrUnsupported = errors.New("no normalised model for resource")
+
+// Normalize maps a provider response onto the common model of resource.
+// body is either the provider's envelope ({"data": ...}) or the bare data.
+func Normalize(providerID, resource string, body []byte) (interface{}, error) {
+	if canonical, ok := aliases[providerID]; ok {
+		providerID = canonical
+	}
+	m, ok := mappers[providerID]
+	if !ok {
+		return nil, fmt.Errorf("no mapper for provider %s", providerID)
+	}
+
+	data := unwrap(body)
+	switch strings.Trim(strings.TrimSpace(resource), "/") {
+	case "domains":
+		return mapList(data, m.domain)
+	case "domain-details":
+		return m.domain(data)
+	case "dns":
+		return mapList(data, m.dns)
+	case "ssl":
+		return m.ssl(data)
+	case "caching":
+		return m.cache(data)
+	case "firewall":
+		return m.firewall(data)
+	case "analytics":
+		return m.traffic(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, resource)
+	}
+}
+
+// unwrap returns the data member of an API envelope, or body itself when
+// it has none.
+func unwrap(body []byte) json.RawMessage {
+	var env struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &env); err == nil && len(env.Data) > 0 && string(env.Data) != "null" {
+		return env.Data
+	}
+	return body
+}
+
+func mapList[T any](data json.RawMessage, fn func(json.RawMessage) (T, error)) ([]T, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("expected a list: %w", err)
+	}
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		v, err := fn(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// rawDNSRecord is the record shape both providers share.
+type rawDNSRecord struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	TTL   int             `json:"ttl"`
+	Value json.RawMessage `json:"value"`
+	Cloud bool            `json:"cloud"`
+}
+
+func (r rawDNSRecord) normalize() DNSRecord {
+	return DNSRecord{
+		ID:      r.ID,
+		Name:    r.Name,
+		Type:    strings.ToUpper(r.Type),
+		TTL:     r.TTL,
+		Values:  recordValues(r.Value),
+		Proxied: r.Cloud,
+	}
+}
+
+// valueKeys are tried in order to pick the meaningful member of an object
+// record value, e.g. {"ip": ...} for A or {"host": ...} for CNAME and MX.
+var valueKeys = []string{"ip", "host", "text", "value", "target", "address"}
+
+// recordValues flattens a record value, which is an object, a list of
+// objects or a plain string depending on the record type.
+func recordValues(raw json.RawMessage) []string {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		var s string
+		if err := json.Unmarshal(item, &s); err == nil {
+			values = append(values, s)
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(item, &obj); err != nil || len(obj) == 0 {
+			continue
+		}
+		values = append(values, objectValue(obj))
+	}
+	return values
+}
+
+func objectValue(obj map[string]interface{}) string {
+	for _, key := range valueKeys {
+		if v, ok := obj[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, obj[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// rawSSL is the SSL settings shape both providers share.
+type rawSSL struct {
+	SSLStatus       bool   `json:"ssl_status"`
+	CertificateMode string `json:"certificate_mode"`
+	TLSVersion      string `json:"tls_version"`
+	HTTPSRedirect   bool   `json:"https_redirect"`
+	HSTSStatus      bool   `json:"hsts_status"`
+	HSTSMaxAge      string `json:"hsts_max_age"`
+	HSTSSubdomain   bool   `json:"hsts_subdomain"`
+	HSTSPreload     bool   `json:"hsts_preload"`
+	QUICStatus      bool   `json:"quic_status"`
+}
+
+func (r rawSSL) normalize() SSLSettings {
+	return SSLSettings{
+		Enabled:         r.SSLStatus,
+		CertificateMode: r.CertificateMode,
+		MinTLSVersion:   r.TLSVersion,
+		HTTPSRedirect:   r.HTTPSRedirect,
+		HSTS:            r.HSTSStatus,
+		HSTSMaxAge:      r.HSTSMaxAge,
+		HSTSSubdomains:  r.HSTSSubdomain,
+		HSTSPreload:     r.HSTSPreload,
+		HTTP3:           r.QUICStatus,
+	}
+}
+
+// rawFirewall covers both firewall rules and firewall settings, which
+// share the same resource on some providers.
+type rawFirewall struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	FilterExpr    string `json:"filter_expr"`
+	Action        string `json:"action"`
+	Priority      int    `json:"priority"`
+	IsEnabled     bool   `json:"is_enabled"`
+	DefaultAction string `json:"default_action"`
+}
+
+func (r rawFirewall) normalize() FirewallRule {
+	if r.DefaultAction != "" && r.Action == "" {
+		return FirewallRule{Name: "default", Action: r.DefaultAction, Enabled: r.IsEnabled}
+	}
+	return FirewallRule{
+		ID:         r.ID,
+		Name:       r.Name,
+		Expression: r.FilterExpr,
+		Action:     r.Action,
+		Priority:   r.Priority,
+		Enabled:    r.IsEnabled,
+	}
+}
+
+// mapFirewall accepts a list of rules or a single settings object.
+func mapFirewall(data json.RawMessage) ([]FirewallRule, error) {
+	var rules []rawFirewall
+	if err := json.Unmarshal(data, &rules); err != nil {
+		var single rawFirewall
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, err
+		}
+		rules = []rawFirewall{single}
+	}
+	out := make([]FirewallRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, r.normalize())
+	}
+	return out, nil
+}
+
+type rawTrafficCounter struct {
+	Total int64  `json:"total"`
+	Saved int64  `json:"saved"`
+	Top   string `json:"top"`
+}
+
+// rawTraffic is the traffic report shape both providers share.
+type rawTraffic struct {
+	Statistics struct {
+		Requests rawTrafficCounter `json:"requests"`
+		Traffics rawTrafficCounter `json:"traffics"`
+	} `json:"statistics"`
+}
+
+func (r rawTraffic) normalize() TrafficReport {
+	stats := r.Statistics
+	report := TrafficReport{
+		Requests:       stats.Requests.Total,
+		CachedRequests: stats.Requests.Saved,
+		Bytes:          stats.Traffics.Total,
+		CachedBytes:    stats.Traffics.Saved,
+		PeakAt:         stats.Requests.Top,
+	}
+	if report.Requests > 0 {
+		report.CacheHitRatio = float64(report.CachedRequests) / float64(report.Requests)
+	}
+	return report
+}
+
+func decode[T any](data json.RawMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeDNSRecords(t *testing.T) {
+	body := []byte(`{"data":[
+		{"id":"1","name":"@","type":"a","ttl":120,"cloud":true,"value":[{"ip":"192.0.2.1","port":null},{"ip":"192.0.2.2"}]},
+		{"id":"2","name":"www","type":"cname","ttl":300,"value":{"host":"example.com","host_header":"source"}},
+		{"id":"3","name":"@","type":"mx","ttl":3600,"value":{"host":"mail.example.com","priority":10}},
+		{"id":"4","name":"@","type":"txt","ttl":300,"value":{"text":"v=spf1 -all"}}
+	]}`)
+
+	for _, provider := range []string{"arvan", "verge"} {
+		got, err := Normalize(provider, "dns", body)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", provider, err)
+		}
+		records := got.([]DNSRecord)
+		want := []DNSRecord{
+			{ID: "1", Name: "@", Type: "A", TTL: 120, Values: []string{"192.0.2.1", "192.0.2.2"}, Proxied: true},
+			{ID: "2", Name: "www", Type: "CNAME", TTL: 300, Values: []string{"example.com"}},
+			{ID: "3", Name: "@", Type: "MX", TTL: 3600, Values: []string{"mail.example.com"}},
+			{ID: "4", Name: "@", Type: "TXT", TTL: 300, Values: []string{"v=spf1 -all"}},
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Fatalf("%s: got %+v, want %+v", provider, records, want)
+		}
+	}
+}
+
+func TestNormalizeDomainPerProvider(t *testing.T) {
+	arvan, err := Normalize("arvancloud", "domain-details",
+		[]byte(`{"data":{"id":"a","domain":"example.com","status":"active","type":"partial","cname_target":"example.com.cdn.arvan"}}`))
+	if err != nil {
+		t.Fatalf("arvan: unexpected error: %v", err)
+	}
+	verge, err := Normalize("verge", "domain-details",
+		[]byte(`{"data":{"id":"v","name":"example.com","status":"active","type":"partial","target_cname":"example.com.cdn.verge"}}`))
+	if err != nil {
+		t.Fatalf("verge: unexpected error: %v", err)
+	}
+
+	if d := arvan.(Domain); d.Name != "example.com" || d.CNAMETarget != "example.com.cdn.arvan" {
+		t.Fatalf("arvan domain not mapped: %+v", d)
+	}
+	if d := verge.(Domain); d.Name != "example.com" || d.CNAMETarget != "example.com.cdn.verge" {
+		t.Fatalf("verge domain not mapped: %+v", d)
+	}
+}
+
+func TestNormalizeFirewallSettingsAndTraffic(t *testing.T) {
+	fw, err := Normalize("arvan", "firewall", []byte(`{"data":{"is_enabled":true,"default_action":"deny"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules := fw.([]FirewallRule); len(rules) != 1 || rules[0].Name != "default" || rules[0].Action != "deny" {
+		t.Fatalf("expected default rule, got %+v", rules)
+	}
+
+	traffic, err := Normalize("arvan", "analytics",
+		[]byte(`{"data":{"statistics":{"requests":{"total":200,"saved":150,"top":"2024-01-01T00:00:00Z"},"traffics":{"total":1000,"saved":600}}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := traffic.(TrafficReport); r.Requests != 200 || r.CacheHitRatio != 0.75 || r.CachedBytes != 600 {
+		t.Fatalf("traffic not mapped: %+v", r)
+	}
+}
+
+func TestNormalizeUnsupported(t *testing.T) {
+	if _, err := Normalize("arvan", "waf", []byte(`{}`)); err == nil {
+		t.Fatal("expected error for resource without a model")
+	}
+	if _, err := Normalize("other", "dns", []byte(`{}`)); err == nil {
+		t.Fatal("expected error for provider without a mapper")
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import "encoding/json"
+
+type vergeDomain struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Type        string   `json:"type"`
+	NSKeys      []string `json:"ns_keys"`
+	TargetCNAME string   `json:"target_cname"`
+}
+
+type vergeCache struct {
+	CacheStatus      string `json:"cache_status"`
+	DeveloperMode    bool   `json:"cache_developer_mode"`
+	CacheMaxAge      string `json:"cache_max_age"`
+	CacheBrowser     string `json:"cache_browser"`
+	CacheMaxSize     int64  `json:"cache_max_size"`
+	CacheArgs        bool   `json:"cache_args"`
+	ConsistentUptime bool   `json:"cache_consistent_uptime"`
+}
+
+var vergeMapper = mapper{
+	domain: func(data json.RawMessage) (Domain, error) {
+		d, err := decode[vergeDomain](data)
+		return Domain{
+			ID:          d.ID,
+			Name:        d.Name,
+			Status:      d.Status,
+			SetupType:   d.Type,
+			NameServers: d.NSKeys,
+			CNAMETarget: d.TargetCNAME,
+		}, err
+	},
+	dns: func(data json.RawMessage) (DNSRecord, error) {
+		r, err := decode[rawDNSRecord](data)
+		return r.normalize(), err
+	},
+	ssl: func(data json.RawMessage) (SSLSettings, error) {
+		s, err := decode[rawSSL](data)
+		return s.normalize(), err
+	},
+	cache: func(data json.RawMessage) (CacheSettings, error) {
+		c, err := decode[vergeCache](data)
+		return CacheSettings{
+			Mode:             c.CacheStatus,
+			DeveloperMode:    c.DeveloperMode,
+			EdgeTTL:          c.CacheMaxAge,
+			BrowserTTL:       c.CacheBrowser,
+			MaxFileSize:      c.CacheMaxSize,
+			QueryStringInKey: c.CacheArgs,
+			ServeStale:       c.ConsistentUptime,
+		}, err
+	},
+	firewall: mapFirewall,
+	traffic: func(data json.RawMessage) (TrafficReport, error) {
+		t, err := decode[rawTraffic](data)
+		return t.normalize(), err
+	},
+}
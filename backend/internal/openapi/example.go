@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// maxExampleDepth bounds generated examples of recursive schemas.
+const maxExampleDepth = 12
+
+// SuccessResponse returns the lowest 2xx status op declares, preferring
+// 200, and its response. It returns 200 and nil when none is declared.
+func (d *Document) SuccessResponse(op *Operation) (int, *Response) {
+	if resp := d.ResolveResponse(op.Responses["200"]); resp != nil {
+		return http.StatusOK, resp
+	}
+	codes := make([]int, 0, len(op.Responses))
+	for key := range op.Responses {
+		if code, err := strconv.Atoi(key); err == nil && code >= 200 && code < 300 {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		if resp := d.ResolveResponse(op.Responses[strconv.Itoa(code)]); resp != nil {
+			return code, resp
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// ResponseExample returns an example body for resp: the JSON media type's
+// example when it satisfies the schema, otherwise one generated from the
+// schema. ok is false when resp declares no JSON content.
+func (d *Document) ResponseExample(resp *Response) (value interface{}, ok bool) {
+	if resp == nil {
+		return nil, false
+	}
+	media, found := resp.Content["application/json"]
+	if !found {
+		for _, m := range resp.Content {
+			media, found = m, true
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	if media.Example != nil && (media.Schema == nil || d.conforms(media.Schema, media.Example)) {
+		return media.Example, true
+	}
+	if media.Schema == nil {
+		return nil, false
+	}
+	return d.ExampleValue(media.Schema), true
+}
+
+// ExampleValue builds a value that satisfies s. The schema's own example,
+// default or first enum member is used where it conforms; recursive
+// references end in an empty list or an omitted property.
+func (d *Document) ExampleValue(s *Schema) interface{} {
+	value, _ := d.exampleValue(s, make(map[string]int), 0)
+	return value
+}
+
+// exampleValue returns false when s recurses into a reference already
+// expanded twice. A second expansion lets required back-references, like a
+// feature's domain, be filled in.
+func (d *Document) exampleValue(s *Schema, expanding map[string]int, depth int) (interface{}, bool) {
+	if s != nil && s.Ref != "" {
+		ref := s.Ref
+		if expanding[ref] >= 2 {
+			return nil, false
+		}
+		expanding[ref]++
+		defer func() { expanding[ref]-- }()
+	}
+	s = d.ResolveSchema(s)
+	if s == nil || depth > maxExampleDepth {
+		return nil, false
+	}
+
+	candidates := append([]interface{}{s.Example}, s.Examples...)
+	candidates = append(candidates, s.Default)
+	if len(s.Enum) > 0 {
+		candidates = append(candidates, s.Enum[0])
+	}
+	for _, c := range candidates {
+		if c != nil && d.conforms(s, c) {
+			return c, true
+		}
+	}
+
+	if len(s.AllOf) > 0 {
+		merged := make(map[string]interface{})
+		for _, sub := range s.AllOf {
+			value, _ := d.exampleValue(sub, expanding, depth+1)
+			if obj, ok := value.(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		for k, v := range d.exampleProperties(s, expanding, depth) {
+			merged[k] = v
+		}
+		return merged, true
+	}
+	for _, alternatives := range [][]*Schema{s.OneOf, s.AnyOf} {
+		for _, alt := range alternatives {
+			if value, ok := d.exampleValue(alt, expanding, depth+1); ok {
+				return value, true
+			}
+		}
+	}
+
+	switch exampleType(s) {
+	case "object":
+		return d.exampleProperties(s, expanding, depth), true
+	case "array":
+		if s.Items == nil || expanding[s.Items.Ref] > 0 {
+			return []interface{}{}, true
+		}
+		if item, ok := d.exampleValue(s.Items, expanding, depth+1); ok {
+			return []interface{}{item}, true
+		}
+		return []interface{}{}, true
+	case "integer":
+		if s.Minimum != nil {
+			return int64(*s.Minimum), true
+		}
+		return 1, true
+	case "number":
+		if s.Minimum != nil {
+			return *s.Minimum, true
+		}
+		return 1.5, true
+	case "boolean":
+		return true, true
+	case "string":
+		return exampleString(s), true
+	default:
+		return nil, true
+	}
+}
+
+func (d *Document) exampleProperties(s *Schema, expanding map[string]int, depth int) map[string]interface{} {
+	out := make(map[string]interface{}, len(s.Properties))
+	for name, prop := range s.Properties {
+		if value, ok := d.exampleValue(prop, expanding, depth+1); ok {
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// exampleType picks the type to generate, preferring a non-null one and
+// inferring objects and arrays from properties and items.
+func exampleType(s *Schema) string {
+	for _, t := range s.Type {
+		if t != "null" {
+			return t
+		}
+	}
+	switch {
+	case len(s.Properties) > 0:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	case len(s.Type) > 0:
+		return "null"
+	default:
+		return "object"
+	}
+}
+
+var formatExamples = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"uuid":      "00000000-0000-4000-8000-000000000000",
+	"hostname":  "example.com",
+	"email":     "user@example.com",
+	"uri":       "https://example.com/",
+	"url":       "https://example.com/",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+}
+
+func exampleString(s *Schema) string {
+	if v, ok := formatExamples[s.Format]; ok {
+		return v
+	}
+	value := "string"
+	if s.MinLength != nil {
+		for len(value) < *s.MinLength {
+			value += "x"
+		}
+	}
+	if s.MaxLength != nil && len(value) > *s.MaxLength {
+		value = value[:*s.MaxLength]
+	}
+	return value
+}
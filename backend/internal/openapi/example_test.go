@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const exampleSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/domains": {
+      "get": {"operationId": "domains.index", "responses": {
+        "201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Domains"}}}},
+        "200": {"content": {"application/json": {
+          "schema": {"$ref": "#/components/schemas/Domains"},
+          "example": {"data": "not a list"}
+        }}}
+      }}
+    },
+    "/ping": {
+      "post": {"operationId": "ping", "responses": {"204": {"description": "No Content"}}}
+    }
+  },
+  "components": {"schemas": {
+    "Domains": {"type": "object", "required": ["data"], "properties": {
+      "data": {"type": "array", "items": {"$ref": "#/components/schemas/Domain"}}
+    }},
+    "Domain": {"type": "object", "required": ["name", "ttl", "features"], "properties": {
+      "name": {"type": "string", "format": "hostname"},
+      "ttl": {"type": "integer", "example": "120"},
+      "status": {"type": "string", "enum": ["active", "pending"]},
+      "features": {"type": "array", "items": {"$ref": "#/components/schemas/Feature"}}
+    }},
+    "Feature": {"type": "object", "required": ["domain"], "properties": {
+      "domain": {"$ref": "#/components/schemas/Domain"}
+    }}
+  }}
+}`
+
+func TestResponseExampleConformsToSchema(t *testing.T) {
+	doc, err := Parse([]byte(exampleSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	op, _ := doc.FindOperation(http.MethodGet, "/domains")
+
+	status, resp := doc.SuccessResponse(op.Operation)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 to be preferred, got %d", status)
+	}
+	body, ok := doc.ResponseExample(resp)
+	if !ok {
+		t.Fatal("expected an example body")
+	}
+	if report := doc.ValidateResponse(op, status, mustJSON(t, body)); !report.Valid {
+		t.Fatalf("generated example violates its schema: %+v", report.Violations)
+	}
+
+	domain := body.(map[string]interface{})["data"].([]interface{})[0].(map[string]interface{})
+	if domain["name"] != "example.com" || domain["status"] != "active" || domain["ttl"] != 1 {
+		t.Fatalf("unexpected generated domain: %+v", domain)
+	}
+
+	ping, _ := doc.FindOperation(http.MethodPost, "/ping")
+	status, resp = doc.SuccessResponse(ping.Operation)
+	if _, ok := doc.ResponseExample(resp); status != http.StatusNoContent || ok {
+		t.Fatalf("expected 204 without a body, got %d", status)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return raw
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FuzzValue is an out-of-range or hostile value for one query parameter
+// of an operation. Kind names the edge case, e.g. "zero" or "sql".
+type FuzzValue struct {
+	Parameter string `json:"parameter"`
+	Kind      string `json:"kind"`
+	Value     string `json:"value"`
+}
+
+const fuzzInvalidEnum = "__invalid_enum__"
+
+// fuzzStrings are sent to every free-text parameter.
+var fuzzStrings = []FuzzValue{
+	{Kind: "empty", Value: ""},
+	{Kind: "unicode", Value: "ünï©ødé 测试 🚀 ‮RTL"},
+	{Kind: "long", Value: strings.Repeat("a", 4096)},
+	{Kind: "sql", Value: "' OR '1'='1' --"},
+	{Kind: "sql-stacked", Value: "1; DROP TABLE domains; --"},
+	{Kind: "script", Value: "<script>alert(1)</script>"},
+	{Kind: "traversal", Value: "../../../etc/passwd"},
+}
+
+// FuzzValues returns boundary and malformed values for the query
+// parameters op declares, derived from their schemas: numbers get zero,
+// negative, out-of-range and overflowing values, enums an unknown member,
+// dates an unparsable one and free text unicode, oversized and injection
+// strings. Path parameters are left alone so calls still reach the
+// operation.
+func (d *Document) FuzzValues(op OperationRef) []FuzzValue {
+	var out []FuzzValue
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		for _, v := range d.fuzzSchema(p.Schema, 0) {
+			v.Parameter = p.Name
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (d *Document) fuzzSchema(s *Schema, depth int) []FuzzValue {
+	s = d.ResolveSchema(s)
+	if s == nil || depth > maxExampleDepth {
+		return fuzzStrings
+	}
+	for _, alternatives := range [][]*Schema{s.OneOf, s.AnyOf, s.AllOf} {
+		if len(alternatives) > 0 {
+			return d.fuzzSchema(alternatives[0], depth+1)
+		}
+	}
+	if len(s.Enum) > 0 {
+		return []FuzzValue{
+			{Kind: "invalid-enum", Value: fuzzInvalidEnum},
+			{Kind: "empty", Value: ""},
+			{Kind: "sql", Value: "' OR '1'='1' --"},
+		}
+	}
+
+	switch exampleType(s) {
+	case "integer", "number":
+		return fuzzNumber(s)
+	case "boolean":
+		return []FuzzValue{
+			{Kind: "wrong-type", Value: "maybe"},
+			{Kind: "out-of-range", Value: "2"},
+		}
+	case "array":
+		return d.fuzzSchema(s.Items, depth+1)
+	case "string":
+		if s.Format == "date-time" || s.Format == "date" {
+			return []FuzzValue{
+				{Kind: "invalid-date", Value: "not-a-date"},
+				{Kind: "impossible-date", Value: "2024-02-30T25:61:00Z"},
+				{Kind: "far-future", Value: "9999-12-31T23:59:59Z"},
+				{Kind: "epoch", Value: "1970-01-01T00:00:00Z"},
+			}
+		}
+		values := append([]FuzzValue{}, fuzzStrings...)
+		if s.MaxLength != nil {
+			values = append(values, FuzzValue{Kind: "above-max-length", Value: strings.Repeat("a", *s.MaxLength+1)})
+		}
+		return values
+	default:
+		return fuzzStrings
+	}
+}
+
+func fuzzNumber(s *Schema) []FuzzValue {
+	values := []FuzzValue{
+		{Kind: "zero", Value: "0"},
+		{Kind: "negative", Value: "-1"},
+		{Kind: "huge", Value: "1000000"},
+		{Kind: "overflow", Value: "99999999999999999999"},
+		{Kind: "wrong-type", Value: "abc"},
+		{Kind: "empty", Value: ""},
+	}
+	if s.Type.Has("integer") {
+		values = append(values, FuzzValue{Kind: "fraction", Value: "1.5"})
+	}
+	if s.Minimum != nil {
+		if below := *s.Minimum - 1; below != 0 && below != -1 {
+			values = append(values, FuzzValue{Kind: "below-minimum", Value: formatNumber(below)})
+		}
+	}
+	if s.Maximum != nil {
+		values = append(values, FuzzValue{Kind: "above-maximum", Value: formatNumber(*s.Maximum + 1)})
+	}
+	return values
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+package openapi
+
+import "testing"
+
+const fuzzSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/domains/{domain}/records": {
+      "parameters": [{"name": "domain", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {"operationId": "records.index", "parameters": [
+        {"name": "page", "in": "query", "schema": {"type": "integer", "minimum": 1}},
+        {"name": "per_page", "in": "query", "schema": {"type": "integer", "maximum": 100}},
+        {"name": "type", "in": "query", "schema": {"type": "string", "enum": ["a", "aaaa"]}},
+        {"name": "since", "in": "query", "schema": {"type": "string", "format": "date-time"}},
+        {"$ref": "#/components/parameters/Search"}
+      ], "responses": {"200": {"description": "OK"}}}
+    }
+  },
+  "components": {"parameters": {
+    "Search": {"name": "search", "in": "query", "schema": {"type": "string", "maxLength": 10}}
+  }}
+}`
+
+func TestFuzzValuesFollowParameterSchemas(t *testing.T) {
+	doc, err := Parse([]byte(fuzzSpec), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, ok := doc.FindOperation("GET", "/domains/example.com/records")
+	if !ok {
+		t.Fatal("operation not found")
+	}
+
+	got := make(map[string]map[string]string)
+	for _, v := range doc.FuzzValues(op) {
+		if v.Parameter == "domain" {
+			t.Fatalf("path parameters must not be fuzzed, got %+v", v)
+		}
+		if got[v.Parameter] == nil {
+			got[v.Parameter] = make(map[string]string)
+		}
+		got[v.Parameter][v.Kind] = v.Value
+	}
+
+	for _, want := range []struct{ param, kind, value string }{
+		{"page", "zero", "0"},
+		{"page", "fraction", "1.5"},
+		{"per_page", "above-maximum", "101"},
+		{"per_page", "huge", "1000000"},
+		{"type", "invalid-enum", fuzzInvalidEnum},
+		{"since", "invalid-date", "not-a-date"},
+		{"search", "sql", "' OR '1'='1' --"},
+		{"search", "above-max-length", "aaaaaaaaaaa"},
+	} {
+		if value, ok := got[want.param][want.kind]; !ok || value != want.value {
+			t.Errorf("%s %s: expected %q, got %q (present %v)", want.param, want.kind, want.value, value, ok)
+		}
+	}
+	if _, ok := got["page"]["below-minimum"]; ok {
+		t.Error("below-minimum duplicates zero when the minimum is 1")
+	}
+	if _, ok := got["search"]["unicode"]; !ok {
+		t.Error("expected a unicode value for free text")
+	}
+}
@@ -0,0 +1,357 @@
+// Package openapi loads the OpenAPI 3 documents bundled for each provider
+// and exposes the parts the backend uses: operations, parameters and
+// response schemas.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	opsOnce sync.Once
+	ops     []OperationRef
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas,omitempty"`
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+	Responses  map[string]*Response  `json:"responses,omitempty"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string      `json:"$ref,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	In          string      `json:"in,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *Schema     `json:"schema,omitempty"`
+	Example     interface{} `json:"example,omitempty"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        Types              `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Default     interface{}        `json:"default,omitempty"`
+	Example     interface{}        `json:"example,omitempty"`
+	Examples    []interface{}      `json:"examples,omitempty"`
+	Description string             `json:"description,omitempty"`
+}
+
+// Types is a schema type, written either as a single name (OpenAPI 3.0) or
+// as a list such as ["array", "null"] (OpenAPI 3.1).
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Has reports whether name is one of the types.
+func (t Types) Has(name string) bool {
+	for _, v := range t {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads a JSON or YAML OpenAPI document.
+func Load(path string) (*Document, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw, filepath.Ext(path))
+}
+
+// Parse decodes a document; ext selects YAML for ".yml"/".yaml" and JSON
+// otherwise.
+func Parse(raw []byte, ext string) (*Document, error) {
+	if ext == ".yml" || ext == ".yaml" {
+		var tree interface{}
+		if err := yaml.Unmarshal(raw, &tree); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(stringKeys(tree))
+		if err != nil {
+			return nil, err
+		}
+		raw = converted
+	}
+
+	var doc Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("document has no paths")
+	}
+	return &doc, nil
+}
+
+// stringKeys turns the map[interface{}]interface{} YAML produces for keys
+// such as response codes into JSON-encodable maps.
+func stringKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = stringKeys(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return out
+	case []interface{}:
+		for i, val := range t {
+			t[i] = stringKeys(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// OperationRef is an operation together with where it lives.
+type OperationRef struct {
+	Method    string
+	Path      string
+	Operation *Operation
+	// Parameters merges the path-level and operation-level parameters,
+	// with references resolved.
+	Parameters []*Parameter
+}
+
+// Operations lists every operation sorted by path, then method. The list
+// is built once and shared; callers must not modify it.
+func (d *Document) Operations() []OperationRef {
+	d.opsOnce.Do(func() { d.ops = d.buildOperations() })
+	return d.ops
+}
+
+func (d *Document) buildOperations() []OperationRef {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var out []OperationRef
+	for _, path := range paths {
+		item := d.Paths[path]
+		for _, m := range []struct {
+			method string
+			op     *Operation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPost, item.Post},
+			{http.MethodPut, item.Put},
+			{http.MethodPatch, item.Patch},
+			{http.MethodDelete, item.Delete},
+		} {
+			if m.op == nil {
+				continue
+			}
+			out = append(out, OperationRef{
+				Method:     m.method,
+				Path:       path,
+				Operation:  m.op,
+				Parameters: d.mergeParameters(item.Parameters, m.op.Parameters),
+			})
+		}
+	}
+	return out
+}
+
+// mergeParameters resolves both lists; operation parameters override path
+// parameters with the same name and location.
+func (d *Document) mergeParameters(pathLevel, opLevel []*Parameter) []*Parameter {
+	var out []*Parameter
+	index := make(map[string]int)
+	for _, list := range [][]*Parameter{pathLevel, opLevel} {
+		for _, p := range list {
+			p = d.ResolveParameter(p)
+			if p == nil {
+				continue
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				out[i] = p
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ResolveParameter follows a #/components/parameters reference.
+func (d *Document) ResolveParameter(p *Parameter) *Parameter {
+	for seen := 0; p != nil && p.Ref != "" && seen < maxRefDepth; seen++ {
+		p = d.Components.Parameters[refName(p.Ref, "#/components/parameters/")]
+	}
+	return p
+}
+
+// ResolveResponse follows a #/components/responses reference.
+func (d *Document) ResolveResponse(r *Response) *Response {
+	for seen := 0; r != nil && r.Ref != "" && seen < maxRefDepth; seen++ {
+		r = d.Components.Responses[refName(r.Ref, "#/components/responses/")]
+	}
+	return r
+}
+
+// ResolveSchema follows a #/components/schemas reference.
+func (d *Document) ResolveSchema(s *Schema) *Schema {
+	for seen := 0; s != nil && s.Ref != "" && seen < maxRefDepth; seen++ {
+		s = d.Components.Schemas[refName(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// maxRefDepth bounds reference chains so a cyclic document cannot hang.
+const maxRefDepth = 32
+
+func refName(ref, prefix string) string {
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(ref, prefix)
+}
+
+// PathParams returns the names of the {placeholders} in path, in order.
+func PathParams(path string) []string {
+	var out []string
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			return out
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return out
+		}
+		out = append(out, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
+
+// maxFieldDepth bounds SchemaFields on deeply nested or recursive schemas.
+const maxFieldDepth = 8
+
+// SchemaFields flattens s into field paths and their types, e.g.
+// "data[].name": "string". allOf members and oneOf/anyOf alternatives all
+// contribute fields.
+func (d *Document) SchemaFields(s *Schema) map[string]string {
+	out := make(map[string]string)
+	d.collectFields(s, "", 0, out)
+	return out
+}
+
+func (d *Document) collectFields(s *Schema, path string, depth int, out map[string]string) {
+	s = d.ResolveSchema(s)
+	if s == nil || depth > maxFieldDepth {
+		return
+	}
+	if path != "" {
+		switch {
+		case len(s.Type) > 0:
+			out[path] = strings.Join(s.Type, "|")
+		case len(s.Properties) > 0:
+			out[path] = "object"
+		case s.Items != nil:
+			out[path] = "array"
+		}
+	}
+
+	for _, group := range [][]*Schema{s.AllOf, s.OneOf, s.AnyOf} {
+		for _, sub := range group {
+			d.collectFields(sub, path, depth+1, out)
+		}
+	}
+	for name, prop := range s.Properties {
+		d.collectFields(prop, joinPath(path, name), depth+1, out)
+	}
+	if s.Items != nil {
+		d.collectFields(s.Items, path+"[]", depth+1, out)
+	}
+}
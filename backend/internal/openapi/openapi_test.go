@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const sampleYAML = `
+openapi: 3.0.0
+info:
+  title: Sample
+  version: "1.0"
+paths:
+  /domains/{domain}/dns-records:
+    parameters:
+      - $ref: "#/components/parameters/domain"
+    get:
+      operationId: dnsRecords.index
+      parameters:
+        - name: page
+          in: query
+          schema:
+            type: integer
+      responses:
+        200:
+          description: ok
+    post:
+      operationId: dnsRecords.store
+components:
+  parameters:
+    domain:
+      name: domain
+      in: path
+      required: true
+      schema:
+        type: string
+  schemas:
+    Record:
+      type: [object, "null"]
+`
+
+func TestParseYAML(t *testing.T) {
+	doc, err := Parse([]byte(sampleYAML), ".yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ops := doc.Operations()
+	if len(ops) != 2 || ops[0].Method != http.MethodGet || ops[1].Method != http.MethodPost {
+		t.Fatalf("expected GET then POST, got %+v", ops)
+	}
+	get := ops[0]
+	if _, ok := get.Operation.Responses["200"]; !ok {
+		t.Fatal("expected integer response code to become a string key")
+	}
+	var names []string
+	for _, p := range get.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"path:domain", "query:page"}) {
+		t.Fatalf("expected merged, resolved parameters, got %v", names)
+	}
+	if s := doc.Components.Schemas["Record"]; !s.Type.Has("object") || !s.Type.Has("null") {
+		t.Fatalf("expected list type, got %v", s.Type)
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	got := PathParams("/v1/ddos/{domain}/rules/{ddos-rule-id}")
+	if !reflect.DeepEqual(got, []string{"domain", "ddos-rule-id"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestBundledSpecsLoad(t *testing.T) {
+	for _, path := range []string{"../../../api/arvancloud-api.yml", "../../../api/vergecloud-api.json"} {
+		doc, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, op := range doc.Operations() {
+			if op.Operation.OperationID == "" {
+				t.Fatalf("%s: %s %s has no operationId", path, op.Method, op.Path)
+			}
+		}
+	}
+}
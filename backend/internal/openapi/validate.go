@@ -0,0 +1,344 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ViolationMissingRequired = "missing_required"
+	ViolationWrongType       = "wrong_type"
+	ViolationUnknownEnum     = "unknown_enum"
+	ViolationInvalidJSON     = "invalid_json"
+)
+
+// maxViolations caps a report so a wholesale shape change stays readable.
+const maxViolations = 50
+
+// maxSchemaDepth guards against recursive schemas.
+const maxSchemaDepth = 64
+
+// Violation is one way a response body departs from its schema. Path
+// locates the value, e.g. "data[0].status".
+type Violation struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// ValidationReport is the outcome of checking one response against the
+// schema its operation declares for the returned status.
+type ValidationReport struct {
+	OperationID string      `json:"operationId,omitempty"`
+	Status      int         `json:"status"`
+	Valid       bool        `json:"valid"`
+	Violations  []Violation `json:"violations,omitempty"`
+	Truncated   bool        `json:"truncated,omitempty"`
+	// Skipped explains why nothing was validated, e.g. no declared schema.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// FindOperation returns the operation whose path template matches path,
+// preferring the template with the most literal segments.
+func (d *Document) FindOperation(method, path string) (OperationRef, bool) {
+	segments := splitPath(path)
+	best, bestScore := OperationRef{}, -1
+	for _, op := range d.Operations() {
+		if op.Method != method {
+			continue
+		}
+		if score := matchTemplate(splitPath(op.Path), segments); score > bestScore {
+			best, bestScore = op, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+func splitPath(path string) []string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchTemplate returns the number of literal segments matched, or -1 when
+// the template does not match.
+func matchTemplate(template, segments []string) int {
+	if len(template) != len(segments) {
+		return -1
+	}
+	score := 0
+	for i, t := range template {
+		switch {
+		case strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}"):
+		case t == segments[i]:
+			score++
+		default:
+			return -1
+		}
+	}
+	return score
+}
+
+// ResponseSchema returns the JSON schema op declares for status, falling
+// back to the 2XX-style range and then to "default".
+func (d *Document) ResponseSchema(op *Operation, status int) *Schema {
+	for _, key := range responseKeys(status) {
+		resp := d.ResolveResponse(op.Responses[key])
+		if resp == nil {
+			continue
+		}
+		if media, ok := resp.Content["application/json"]; ok && media.Schema != nil {
+			return media.Schema
+		}
+		for _, media := range resp.Content {
+			if media.Schema != nil {
+				return media.Schema
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// DeclaresStatus reports whether op documents a response for status,
+// exactly, through its range or through "default".
+func (d *Document) DeclaresStatus(op *Operation, status int) bool {
+	for _, key := range responseKeys(status) {
+		if d.ResolveResponse(op.Responses[key]) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func responseKeys(status int) []string {
+	code := strconv.Itoa(status)
+	return []string{code, code[:1] + "XX", code[:1] + "xx", "default"}
+}
+
+// ValidateResponse checks body against the schema op declares for status.
+func (d *Document) ValidateResponse(op OperationRef, status int, body []byte) *ValidationReport {
+	report := &ValidationReport{OperationID: op.Operation.OperationID, Status: status}
+	schema := d.ResponseSchema(op.Operation, status)
+	if schema == nil {
+		report.Valid = true
+		report.Skipped = fmt.Sprintf("no response schema for status %d", status)
+		return report
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		report.Violations = []Violation{{Kind: ViolationInvalidJSON, Message: err.Error()}}
+		return report
+	}
+
+	v := &validator{doc: d}
+	v.validate(schema, value, "", 0)
+	report.Violations = v.violations
+	report.Truncated = v.truncated
+	report.Valid = len(v.violations) == 0
+	return report
+}
+
+type validator struct {
+	doc        *Document
+	violations []Violation
+	truncated  bool
+}
+
+func (v *validator) add(path, kind, format string, args ...interface{}) {
+	if len(v.violations) >= maxViolations {
+		v.truncated = true
+		return
+	}
+	if path == "" {
+		path = "$"
+	}
+	v.violations = append(v.violations, Violation{Path: path, Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(schema *Schema, value interface{}, path string, depth int) {
+	schema = v.doc.ResolveSchema(schema)
+	if schema == nil || depth > maxSchemaDepth {
+		return
+	}
+
+	for _, sub := range schema.AllOf {
+		v.validate(v.withoutOverridden(sub, schema.Properties), value, path, depth+1)
+	}
+	if len(schema.OneOf) > 0 {
+		v.validateAlternatives(schema.OneOf, value, path, depth)
+	}
+	if len(schema.AnyOf) > 0 {
+		v.validateAlternatives(schema.AnyOf, value, path, depth)
+	}
+
+	// With alternatives, they decide the type; some specs pair oneOf
+	// members of other types with a contradicting "type: object". The
+	// schema's own enum, required and property checks still apply.
+	checkType := len(schema.Type) > 0 && len(schema.OneOf)+len(schema.AnyOf) == 0
+
+	if value == nil {
+		if checkType && !schema.Nullable && !schema.Type.Has("null") {
+			v.add(path, ViolationWrongType, "expected %s, got null", strings.Join(schema.Type, " or "))
+		}
+		return
+	}
+
+	if checkType && !typeMatches(schema.Type, value) {
+		v.add(path, ViolationWrongType, "expected %s, got %s", strings.Join(schema.Type, " or "), jsonType(value))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		v.add(path, ViolationUnknownEnum, "value %v is not one of %v", value, schema.Enum)
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := t[name]; !ok {
+				v.add(joinPath(path, name), ViolationMissingRequired, "required field %q is missing", name)
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if child, ok := t[name]; ok {
+				v.validate(schema.Properties[name], child, joinPath(path, name), depth+1)
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range t {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), depth+1)
+			}
+		}
+	}
+}
+
+// withoutOverridden drops from an allOf member the properties its parent
+// redeclares, since specs use that to narrow an inherited envelope, e.g.
+// turning a generic data object into a list.
+func (v *validator) withoutOverridden(sub *Schema, parent map[string]*Schema) *Schema {
+	sub = v.doc.ResolveSchema(sub)
+	if sub == nil || len(parent) == 0 || len(sub.Properties) == 0 {
+		return sub
+	}
+	clone := *sub
+	clone.Properties = make(map[string]*Schema, len(sub.Properties))
+	for name, prop := range sub.Properties {
+		if _, ok := parent[name]; !ok {
+			clone.Properties[name] = prop
+		}
+	}
+	return &clone
+}
+
+// conforms reports whether value satisfies s without violations.
+func (d *Document) conforms(s *Schema, value interface{}) bool {
+	v := &validator{doc: d}
+	v.validate(s, value, "", 0)
+	return len(v.violations) == 0
+}
+
+// validateAlternatives accepts value when any alternative matches, and
+// otherwise reports the violations of the closest alternative.
+func (v *validator) validateAlternatives(alternatives []*Schema, value interface{}, path string, depth int) {
+	var best []Violation
+	for i, alt := range alternatives {
+		trial := &validator{doc: v.doc}
+		trial.validate(alt, value, path, depth+1)
+		if len(trial.violations) == 0 {
+			return
+		}
+		if i == 0 || len(trial.violations) < len(best) {
+			best = trial.violations
+		}
+	}
+	for _, violation := range best {
+		v.add(violation.Path, violation.Kind, "%s", violation.Message)
+	}
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func typeMatches(types Types, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := value.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if t == math.Trunc(t) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "null"
+	}
+}
+
+// enumContains compares by printed value, since YAML and JSON documents
+// decode numeric enum members differently.
+func enumContains(enum []interface{}, value interface{}) bool {
+	want := fmt.Sprint(value)
+	for _, e := range enum {
+		if e == nil {
+			continue
+		}
+		if fmt.Sprint(e) == want {
+			return true
+		}
+	}
+	return false
+}
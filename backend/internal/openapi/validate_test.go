@@ -0,0 +1,170 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+)
+
+const validateSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/domains/{domain}": {
+      "get": {"operationId": "domains.show", "responses": {
+        "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/DomainResponse"}}}}
+      }}
+    },
+    "/domains/dns-service": {
+      "get": {"operationId": "domains.dnsService", "responses": {}}
+    }
+  },
+  "components": {"schemas": {
+    "DomainResponse": {"type": "object", "required": ["data"], "properties": {
+      "data": {"$ref": "#/components/schemas/Domain"}
+    }},
+    "Domain": {"type": "object", "required": ["id", "name"], "properties": {
+      "id": {"type": "string"},
+      "name": {"type": "string"},
+      "status": {"type": "string", "enum": ["pending", "active"]},
+      "ttl": {"type": "integer"},
+      "cname": {"type": "string", "nullable": true},
+      "ns_keys": {"type": "array", "items": {"type": "string"}},
+      "value": {"oneOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}]}
+    }}
+  }}
+}`
+
+func TestValidateResponse(t *testing.T) {
+	doc, err := Parse([]byte(validateSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	op, ok := doc.FindOperation(http.MethodGet, "/domains/example.com")
+	if !ok || op.Operation.OperationID != "domains.show" {
+		t.Fatalf("expected domains.show, got %+v", op)
+	}
+
+	valid := doc.ValidateResponse(op, 200, []byte(`{"data":{"id":"1","name":"example.com","status":"active","ttl":120,"cname":null,"ns_keys":["a"],"value":["x"]}}`))
+	if !valid.Valid || len(valid.Violations) != 0 {
+		t.Fatalf("expected valid body, got %+v", valid.Violations)
+	}
+
+	report := doc.ValidateResponse(op, 200, []byte(`{"data":{"id":1,"status":"moved","ttl":1.5,"ns_keys":["a",2],"value":true}}`))
+	if report.Valid {
+		t.Fatal("expected violations")
+	}
+	got := make([]string, 0, len(report.Violations))
+	for _, v := range report.Violations {
+		got = append(got, v.Kind+" "+v.Path)
+	}
+	sort.Strings(got)
+	want := []string{
+		"missing_required data.name",
+		"unknown_enum data.status",
+		"wrong_type data.id",
+		"wrong_type data.ns_keys[1]",
+		"wrong_type data.ttl",
+		"wrong_type data.value",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestValidateResponseWithoutSchema(t *testing.T) {
+	doc, err := Parse([]byte(validateSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	op, ok := doc.FindOperation(http.MethodGet, "/domains/dns-service")
+	if !ok || op.Operation.OperationID != "domains.dnsService" {
+		t.Fatalf("expected literal path to win, got %+v", op)
+	}
+	if report := doc.ValidateResponse(op, 200, []byte(`{}`)); !report.Valid || report.Skipped == "" {
+		t.Fatalf("expected skipped report, got %+v", report)
+	}
+}
+
+const overrideSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/health-checks": {
+      "get": {"operationId": "health-checks.index", "responses": {
+        "200": {"content": {"application/json": {"schema": {
+          "allOf": [{"$ref": "#/components/schemas/DataResponse"}],
+          "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/Match"}}}
+        }}}}
+      }}
+    }
+  },
+  "components": {"schemas": {
+    "DataResponse": {"type": "object", "required": ["data"], "properties": {
+      "data": {"type": "object"},
+      "message": {"type": "string"}
+    }},
+    "Match": {"type": "object", "oneOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}]}
+  }}
+}`
+
+func TestValidateResponseHonoursSpecQuirks(t *testing.T) {
+	doc, err := Parse([]byte(overrideSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	op, _ := doc.FindOperation(http.MethodGet, "/health-checks")
+
+	report := doc.ValidateResponse(op, 200, []byte(`{"data": ["IR", ["10.0.0.0/8"]], "message": "ok"}`))
+	if !report.Valid {
+		t.Fatalf("expected redeclared data and typed oneOf members to pass, got %+v", report.Violations)
+	}
+
+	report = doc.ValidateResponse(op, 200, []byte(`{"data": [1], "message": 2}`))
+	if report.Valid || len(report.Violations) != 2 {
+		t.Fatalf("expected the inherited message and the item to be checked, got %+v", report.Violations)
+	}
+}
+
+const alternativesSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/records": {
+      "get": {"operationId": "records.show", "responses": {
+        "200": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "required": ["type"],
+          "properties": {"type": {"type": "string", "enum": ["a", "cname"]}},
+          "oneOf": [
+            {"type": "object", "properties": {"ip": {"type": "string"}}},
+            {"type": "object", "properties": {"host": {"type": "string"}}}
+          ]
+        }}}}
+      }}
+    }
+  }
+}`
+
+func TestValidateResponseChecksSchemaBesideAlternatives(t *testing.T) {
+	doc, err := Parse([]byte(alternativesSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	op, _ := doc.FindOperation(http.MethodGet, "/records")
+
+	if report := doc.ValidateResponse(op, 200, []byte(`{"type": "a", "ip": "10.0.0.1"}`)); !report.Valid {
+		t.Fatalf("expected a valid record, got %+v", report.Violations)
+	}
+
+	report := doc.ValidateResponse(op, 200, []byte(`{"ip": "10.0.0.1"}`))
+	if report.Valid || report.Violations[0].Kind != ViolationMissingRequired {
+		t.Fatalf("expected the missing required field to be reported, got %+v", report.Violations)
+	}
+	report = doc.ValidateResponse(op, 200, []byte(`{"type": "mx"}`))
+	if report.Valid || report.Violations[0].Kind != ViolationUnknownEnum {
+		t.Fatalf("expected the property enum to be checked, got %+v", report.Violations)
+	}
+}
@@ -0,0 +1,181 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// CatalogOperation describes one GET operation of a provider's OpenAPI
+// document, callable through CallOperation.
+type CatalogOperation struct {
+	OperationID string   `json:"operationId"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	PathParams  []string `json:"pathParams,omitempty"`
+	QueryParams []string `json:"queryParams,omitempty"`
+}
+
+// ErrUnknownOperation is returned for an operationId missing from the spec.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// LoadSpecs reads every provider's OpenAPI document from its SpecPath.
+// Providers whose spec fails to load keep an empty catalog; the returned
+// error lists them.
+func (r *Registry) LoadSpecs() error {
+	var errs []error
+	for id, provider := range r.configs {
+		if provider.SpecPath == "" {
+			continue
+		}
+		doc, err := openapi.Load(provider.SpecPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		r.SetSpec(id, doc)
+	}
+	return errors.Join(errs...)
+}
+
+// SetSpec installs doc as the OpenAPI document of providerID.
+func (r *Registry) SetSpec(providerID string, doc *openapi.Document) {
+	index := make(map[string]openapi.OperationRef)
+	for _, op := range doc.Operations() {
+		if op.Operation.OperationID != "" {
+			index[op.Operation.OperationID] = op
+		}
+	}
+
+	r.specsMu.Lock()
+	defer r.specsMu.Unlock()
+	r.specs[providerID] = doc
+	r.operations[providerID] = index
+}
+
+// Spec returns the OpenAPI document of providerID, or nil if none loaded.
+func (r *Registry) Spec(providerID string) *openapi.Document {
+	r.specsMu.RLock()
+	defer r.specsMu.RUnlock()
+	return r.specs[providerID]
+}
+
+// Operation returns the spec operation with operationID.
+func (r *Registry) Operation(providerID, operationID string) (openapi.OperationRef, bool) {
+	r.specsMu.RLock()
+	defer r.specsMu.RUnlock()
+	op, ok := r.operations[providerID][operationID]
+	return op, ok
+}
+
+// Catalog lists the GET operations of providerID sorted by operationId.
+func (r *Registry) Catalog(providerID string) []CatalogOperation {
+	r.specsMu.RLock()
+	index := r.operations[providerID]
+	r.specsMu.RUnlock()
+
+	out := make([]CatalogOperation, 0, len(index))
+	for id, op := range index {
+		if op.Method != http.MethodGet {
+			continue
+		}
+		entry := CatalogOperation{
+			OperationID: id,
+			Method:      op.Method,
+			Path:        op.Path,
+			Summary:     op.Operation.Summary,
+			Tags:        op.Operation.Tags,
+			PathParams:  openapi.PathParams(op.Path),
+		}
+		for _, p := range op.Parameters {
+			if p.In == "query" {
+				entry.QueryParams = append(entry.QueryParams, p.Name)
+			}
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OperationID < out[j].OperationID })
+	return out
+}
+
+// CallOperation issues the GET operation operationID. Path placeholders and
+// declared query parameters are taken from params; {domain} defaults to the
+// provider's configured domain. The call uses the resource timeout keyed by
+// operationID, if any.
+func (r *Registry) CallOperation(ctx context.Context, providerID, operationID string, params url.Values) (Response, error) {
+	resource, err := r.OperationPath(ctx, providerID, operationID, params)
+	if err != nil {
+		return Response{}, err
+	}
+	return r.send(ctx, providerID, http.MethodGet, resource, r.Timeout(providerID, operationID), nil)
+}
+
+// ProbeOperation is CallOperation for deliberately invalid calls, such as
+// fuzzed parameters. Probes are sent once, never retried, so a value that
+// triggers a 5xx or 429 does not multiply the load on the provider. They
+// are not counted toward API coverage.
+func (r *Registry) ProbeOperation(ctx context.Context, providerID, operationID string, params url.Values) (Response, error) {
+	resource, err := r.OperationPath(ctx, providerID, operationID, params)
+	if err != nil {
+		return Response{}, err
+	}
+	return r.sendWithRetry(ctx, RetryPolicy{MaxAttempts: 1}, providerID, http.MethodGet, resource, r.Timeout(providerID, operationID), nil, nil)
+}
+
+// OperationPath builds the path, relative to the provider's API base, that
+// CallOperation would request with ctx.
+func (r *Registry) OperationPath(ctx context.Context, providerID, operationID string, params url.Values) (string, error) {
+	provider, err := r.Provider(ctx, providerID)
+	if err != nil {
+		return "", err
+	}
+	op, ok := r.Operation(providerID, operationID)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownOperation, operationID)
+	}
+	if op.Method != http.MethodGet {
+		return "", fmt.Errorf("operation %s is %s; only GET operations can be called", operationID, op.Method)
+	}
+
+	path := op.Path
+	for _, name := range openapi.PathParams(op.Path) {
+		value := params.Get(name)
+		if value == "" && name == "domain" {
+			value = provider.Domain
+		}
+		if value == "" {
+			return "", fmt.Errorf("missing path parameter: %s", name)
+		}
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+	}
+
+	query := url.Values{}
+	for _, p := range op.Parameters {
+		if p.In == "query" && params.Has(p.Name) {
+			query[p.Name] = params[p.Name]
+		}
+	}
+
+	path = trimBasePath(provider.APIBase, path)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// trimBasePath drops the part of a spec path already present in the API
+// base, e.g. the /v1 of VergeCloud's paths when the base ends in /v1.
+func trimBasePath(apiBase, path string) string {
+	if base := basePath(apiBase); base != "" && strings.HasPrefix(path, base+"/") {
+		return strings.TrimPrefix(path, base)
+	}
+	return path
+}
@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+const catalogSpec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "Verge", "version": "1"},
+  "paths": {
+    "/v1/dns/{domain}/records": {
+      "get": {"operationId": "list-dns", "parameters": [
+        {"name": "domain", "in": "path", "required": true},
+        {"name": "page", "in": "query"}
+      ]},
+      "post": {"operationId": "create-dns"}
+    },
+    "/v1/ddos/{domain}/rules/{ddos-rule-id}": {
+      "get": {"operationId": "get-ddos-rule"}
+    }
+  }
+}`
+
+func TestCallOperationFillsParameters(t *testing.T) {
+	var gotPath, gotQuery string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", APIBase: api.URL + "/v1", Domain: "example.com"},
+		},
+	})
+	doc, err := openapi.Parse([]byte(catalogSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	reg.SetSpec("verge", doc)
+
+	if ops := reg.Catalog("verge"); len(ops) != 2 {
+		t.Fatalf("expected only the 2 GET operations, got %+v", ops)
+	}
+
+	resp, err := reg.CallOperation(context.Background(), "verge", "list-dns", url.Values{"page": {"2"}, "other": {"x"}})
+	if err != nil || resp.Status != http.StatusOK {
+		t.Fatalf("unexpected result: %d %v", resp.Status, err)
+	}
+	if gotPath != "/v1/dns/example.com/records" || gotQuery != "page=2" {
+		t.Fatalf("unexpected request %s?%s", gotPath, gotQuery)
+	}
+
+	if _, err := reg.CallOperation(context.Background(), "verge", "get-ddos-rule", nil); err == nil {
+		t.Fatal("expected error for missing path parameter")
+	}
+	if _, err := reg.CallOperation(context.Background(), "verge", "create-dns", nil); err == nil {
+		t.Fatal("expected error for non-GET operation")
+	}
+	if _, err := reg.CallOperation(context.Background(), "verge", "nope", nil); err == nil {
+		t.Fatal("expected error for unknown operation")
+	}
+}
+
+func TestValidateResponseMatchesBasePath(t *testing.T) {
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", APIBase: "https://api.example.com/v1", Domain: "example.com"},
+		},
+	})
+	if reg.ValidateResponse(context.Background(), "verge", http.MethodGet, "dns", 200, []byte(`{}`)) != nil {
+		t.Fatal("expected no report without a spec")
+	}
+
+	spec := `{"openapi": "3.1.0", "paths": {"/v1/dns/{domain}/records": {"get": {"operationId": "list-dns",
+		"responses": {"200": {"content": {"application/json": {"schema": {"type": "object", "required": ["data"]}}}}}}}}}`
+	doc, err := openapi.Parse([]byte(spec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	reg.SetSpec("verge", doc)
+
+	report := reg.ValidateResponse(context.Background(), "verge", http.MethodGet, "dns", 200, []byte(`{"items":[]}`))
+	if report == nil || report.OperationID != "list-dns" || report.Valid {
+		t.Fatalf("expected a failed list-dns report, got %+v", report)
+	}
+}
+
+func TestProbeOperationIsNotRetried(t *testing.T) {
+	calls := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", APIBase: api.URL + "/v1", Domain: "example.com"},
+		},
+	})
+	reg.SetRetryPolicy(RetryPolicy{MaxAttempts: 3})
+	doc, err := openapi.Parse([]byte(catalogSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	reg.SetSpec("verge", doc)
+
+	resp, err := reg.ProbeOperation(context.Background(), "verge", "list-dns", url.Values{"page": {"0"}})
+	if err != nil || resp.Status != http.StatusServiceUnavailable || resp.Attempts != 1 || calls != 1 {
+		t.Fatalf("expected a single attempt, got status %d after %d attempts (%d calls): %v", resp.Status, resp.Attempts, calls, err)
+	}
+}
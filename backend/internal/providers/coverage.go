@@ -0,0 +1,286 @@
+package providers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// Checklist categories, named after the sections of
+// docs/CDN-API-Testing-Checklist.md.
+const (
+	CategoryAuth         = "Authentication & API Keys"
+	CategoryDomains      = "Domain Management"
+	CategorySSL          = "SSL/TLS Management"
+	CategoryDNS          = "DNS Management"
+	CategoryCaching      = "Caching & Performance"
+	CategoryAcceleration = "Acceleration & Optimization"
+	CategorySecurity     = "Security & Firewall"
+	CategoryLoadBalance  = "Load Balancing"
+	CategoryPageRules    = "Page Rules & Custom Logic"
+	CategoryAnalytics    = "Analytics & Reporting"
+	CategoryHealthChecks = "Health Checks & Monitoring"
+	CategoryLogging      = "Logging & Monitoring"
+	CategoryCustomPages  = "Custom Pages & Redirects"
+	CategoryAdvanced     = "Advanced Features"
+	CategoryOrganization = "Organization & User Management"
+	CategoryActionLogs   = "Action Logs & Audit"
+	CategoryFeatures     = "Feature Management"
+	CategoryLists        = "Lists & IP Management"
+	CategoryOther        = "Other"
+)
+
+var checklistOrder = []string{
+	CategoryAuth, CategoryDomains, CategorySSL, CategoryDNS, CategoryCaching,
+	CategoryAcceleration, CategorySecurity, CategoryLoadBalance, CategoryPageRules,
+	CategoryAnalytics, CategoryHealthChecks, CategoryLogging, CategoryCustomPages,
+	CategoryAdvanced, CategoryOrganization, CategoryActionLogs, CategoryFeatures,
+	CategoryLists, CategoryOther,
+}
+
+// tagCategories maps the (lower-cased) operation tags of both specs to a
+// checklist category.
+var tagCategories = map[string]string{
+	"internal":              CategoryAuth,
+	"domain":                CategoryDomains,
+	"domain claim":          CategoryDomains,
+	"domain transfer":       CategoryDomains,
+	"plan":                  CategoryDomains,
+	"ssl/tls":               CategorySSL,
+	"dns":                   CategoryDNS,
+	"dns management":        CategoryDNS,
+	"caching":               CategoryCaching,
+	"acceleration":          CategoryAcceleration,
+	"firewall":              CategorySecurity,
+	"waf":                   CategorySecurity,
+	"ddos":                  CategorySecurity,
+	"rate limit":            CategorySecurity,
+	"rate limiting":         CategorySecurity,
+	"load balancer":         CategoryLoadBalance,
+	"load balancing":        CategoryLoadBalance,
+	"page rule":             CategoryPageRules,
+	"report":                CategoryAnalytics,
+	"reports":               CategoryAnalytics,
+	"aggregated reports":    CategoryAnalytics,
+	"health check":          CategoryHealthChecks,
+	"active health check":   CategoryHealthChecks,
+	"smart checker":         CategoryHealthChecks,
+	"troubleshoot":          CategoryHealthChecks,
+	"log forwarder":         CategoryLogging,
+	"log forwarders":        CategoryLogging,
+	"metric exporters":      CategoryLogging,
+	"custom page":           CategoryCustomPages,
+	"custom pages":          CategoryCustomPages,
+	"redirect":              CategoryCustomPages,
+	"cdn apps":              CategoryAdvanced,
+	"transport layer proxy": CategoryAdvanced,
+	"organization":          CategoryOrganization,
+	"user":                  CategoryOrganization,
+	"action log":            CategoryActionLogs,
+	"features":              CategoryFeatures,
+	"list":                  CategoryLists,
+	"ip list":               CategoryLists,
+}
+
+// OperationCategory returns the checklist category of op, from its first
+// recognised tag.
+func OperationCategory(op *openapi.Operation) string {
+	for _, tag := range op.Tags {
+		if category, ok := tagCategories[strings.ToLower(strings.TrimSpace(tag))]; ok {
+			return category
+		}
+	}
+	return CategoryOther
+}
+
+const (
+	OutcomeSuccess     = "success"
+	OutcomeClientError = "client_error"
+	OutcomeServerError = "server_error"
+	OutcomeError       = "error"
+)
+
+// OperationCoverage is what the registry has seen of one spec operation.
+type OperationCoverage struct {
+	OperationID  string `json:"operationId"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Calls        int    `json:"calls"`
+	Successes    int    `json:"successes"`
+	LastStatus   int    `json:"lastStatus,omitempty"`
+	LastOutcome  string `json:"lastOutcome,omitempty"`
+	LastCalledAt string `json:"lastCalledAt,omitempty"`
+}
+
+// CategoryCoverage counts the operations of one checklist category.
+type CategoryCoverage struct {
+	Category   string              `json:"category"`
+	Operations int                 `json:"operations"`
+	Exercised  int                 `json:"exercised"`
+	Passing    int                 `json:"passing"`
+	Details    []OperationCoverage `json:"details,omitempty"`
+}
+
+// ProviderCoverage is the coverage of one provider's spec.
+type ProviderCoverage struct {
+	Provider   string             `json:"provider"`
+	Operations int                `json:"operations"`
+	Exercised  int                `json:"exercised"`
+	Passing    int                `json:"passing"`
+	Categories []CategoryCoverage `json:"categories"`
+}
+
+type coverageRecord struct {
+	calls      int
+	successes  int
+	lastStatus int
+	outcome    string
+	lastAt     time.Time
+}
+
+// coverageTracker remembers the outcome of every call that maps to a spec
+// operation, for the lifetime of the process.
+type coverageTracker struct {
+	mu      sync.Mutex
+	records map[string]map[string]*coverageRecord
+}
+
+func (t *coverageTracker) record(providerID, operationID string, status int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.records == nil {
+		t.records = make(map[string]map[string]*coverageRecord)
+	}
+	if t.records[providerID] == nil {
+		t.records[providerID] = make(map[string]*coverageRecord)
+	}
+	rec := t.records[providerID][operationID]
+	if rec == nil {
+		rec = &coverageRecord{}
+		t.records[providerID][operationID] = rec
+	}
+
+	rec.calls++
+	rec.lastStatus = status
+	rec.lastAt = time.Now()
+	rec.outcome = callOutcome(status, err)
+	if rec.outcome == OutcomeSuccess {
+		rec.successes++
+	}
+}
+
+func (t *coverageTracker) get(providerID, operationID string) (coverageRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rec, ok := t.records[providerID][operationID]
+	if !ok {
+		return coverageRecord{}, false
+	}
+	return *rec, true
+}
+
+func callOutcome(status int, err error) string {
+	switch {
+	case err != nil:
+		return OutcomeError
+	case status >= 200 && status < 300:
+		return OutcomeSuccess
+	case status >= 400 && status < 500:
+		return OutcomeClientError
+	default:
+		return OutcomeServerError
+	}
+}
+
+// matchOperation finds the spec operation a call to method+resource with
+// ctx hits, resolving the resource against ctx's scoped domain. Spec paths
+// either start below the API base (Arvan) or repeat its path, like
+// VergeCloud's /v1.
+func (r *Registry) matchOperation(ctx context.Context, providerID, method, resource string) (openapi.OperationRef, bool) {
+	doc := r.Spec(providerID)
+	if doc == nil {
+		return openapi.OperationRef{}, false
+	}
+	provider, err := r.Provider(ctx, providerID)
+	if err != nil {
+		return openapi.OperationRef{}, false
+	}
+	endpoint, err := resolveEndpoint(provider, resource)
+	if err != nil {
+		return openapi.OperationRef{}, false
+	}
+	endpoint = ensureLeadingSlash(endpoint)
+	for _, path := range []string{endpoint, basePath(provider.APIBase) + endpoint} {
+		if op, ok := doc.FindOperation(strings.ToUpper(method), path); ok {
+			return op, true
+		}
+	}
+	return openapi.OperationRef{}, false
+}
+
+// recordCoverage notes the outcome of a call if it maps to an operation.
+func (r *Registry) recordCoverage(ctx context.Context, providerID, method, resource string, status int, err error) {
+	if op, ok := r.matchOperation(ctx, providerID, method, resource); ok {
+		r.coverage.record(providerID, op.Operation.OperationID, status, err)
+	}
+}
+
+// Coverage reports, per provider and checklist category, how many spec
+// operations exist and how many were exercised since startup. With details,
+// each category also lists its operations.
+func (r *Registry) Coverage(providerIDs []string, details bool) []ProviderCoverage {
+	out := make([]ProviderCoverage, 0, len(providerIDs))
+	for _, id := range providerIDs {
+		doc := r.Spec(id)
+		if doc == nil {
+			continue
+		}
+
+		byCategory := make(map[string]*CategoryCoverage)
+		for _, op := range doc.Operations() {
+			name := OperationCategory(op.Operation)
+			cat := byCategory[name]
+			if cat == nil {
+				cat = &CategoryCoverage{Category: name}
+				byCategory[name] = cat
+			}
+			cat.Operations++
+
+			entry := OperationCoverage{OperationID: op.Operation.OperationID, Method: op.Method, Path: op.Path}
+			if rec, ok := r.coverage.get(id, entry.OperationID); ok {
+				cat.Exercised++
+				if rec.outcome == OutcomeSuccess {
+					cat.Passing++
+				}
+				entry.Calls = rec.calls
+				entry.Successes = rec.successes
+				entry.LastStatus = rec.lastStatus
+				entry.LastOutcome = rec.outcome
+				entry.LastCalledAt = rec.lastAt.UTC().Format(time.RFC3339)
+			}
+			if details {
+				cat.Details = append(cat.Details, entry)
+			}
+		}
+
+		provider := ProviderCoverage{Provider: id}
+		for _, name := range checklistOrder {
+			cat, ok := byCategory[name]
+			if !ok {
+				continue
+			}
+			sort.Slice(cat.Details, func(i, j int) bool { return cat.Details[i].OperationID < cat.Details[j].OperationID })
+			provider.Operations += cat.Operations
+			provider.Exercised += cat.Exercised
+			provider.Passing += cat.Passing
+			provider.Categories = append(provider.Categories, *cat)
+		}
+		out = append(out, provider)
+	}
+	return out
+}
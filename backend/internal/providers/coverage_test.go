@@ -0,0 +1,134 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+const coverageSpec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "Verge", "version": "1"},
+  "paths": {
+    "/v1/domains": {
+      "get": {"operationId": "list-domains", "tags": ["Domain"]}
+    },
+    "/v1/dns/{domain}/records": {
+      "get": {"operationId": "list-dns", "tags": ["DNS Management"]},
+      "post": {"operationId": "create-dns", "tags": ["DNS Management"]}
+    },
+    "/v1/widgets": {
+      "get": {"operationId": "list-widgets"}
+    }
+  }
+}`
+
+func TestCoverageCountsPerCategory(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/dns/") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", APIBase: api.URL + "/v1", Domain: "example.com"},
+		},
+	})
+	doc, err := openapi.Parse([]byte(coverageSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	reg.SetSpec("verge", doc)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := reg.Send(ctx, "verge", http.MethodGet, "domains", nil); err != nil {
+			t.Fatalf("domains: %v", err)
+		}
+	}
+	if _, err := reg.CallOperation(ctx, "verge", "list-dns", nil); err != nil {
+		t.Fatalf("list-dns: %v", err)
+	}
+
+	cov := reg.Coverage([]string{"verge", "arvan"}, true)
+	if len(cov) != 1 {
+		t.Fatalf("expected only the provider with a spec, got %+v", cov)
+	}
+	got := cov[0]
+	if got.Operations != 4 || got.Exercised != 2 || got.Passing != 1 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+
+	var names []string
+	byName := make(map[string]CategoryCoverage)
+	for _, cat := range got.Categories {
+		names = append(names, cat.Category)
+		byName[cat.Category] = cat
+	}
+	if strings.Join(names, ",") != CategoryDomains+","+CategoryDNS+","+CategoryOther {
+		t.Fatalf("categories not in checklist order: %v", names)
+	}
+
+	domains := byName[CategoryDomains]
+	if domains.Exercised != 1 || domains.Passing != 1 || domains.Details[0].Calls != 2 {
+		t.Fatalf("unexpected domain coverage: %+v", domains)
+	}
+	dns := byName[CategoryDNS]
+	if dns.Operations != 2 || dns.Exercised != 1 || dns.Passing != 0 {
+		t.Fatalf("unexpected DNS coverage: %+v", dns)
+	}
+	for _, op := range dns.Details {
+		if op.OperationID == "list-dns" && (op.LastStatus != http.StatusForbidden || op.LastOutcome != OutcomeClientError) {
+			t.Fatalf("unexpected list-dns record: %+v", op)
+		}
+		if op.OperationID == "create-dns" && op.Calls != 0 {
+			t.Fatalf("create-dns was never called: %+v", op)
+		}
+	}
+
+	if summary := reg.Coverage([]string{"verge"}, false); summary[0].Categories[0].Details != nil {
+		t.Fatal("expected no details unless requested")
+	}
+}
+
+func TestCoverageResolvesScopedDomain(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {
+				ID:      "verge",
+				APIBase: api.URL + "/v1",
+				Domains: []config.DomainConfig{{Name: "shop", Domain: "shop.example.net"}},
+			},
+		},
+	})
+	doc, err := openapi.Parse([]byte(coverageSpec), ".json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	reg.SetSpec("verge", doc)
+
+	ctx := WithScope(context.Background(), Scope{Domain: "shop"})
+	resp, err := reg.Send(ctx, "verge", http.MethodGet, "dns", nil)
+	if err != nil {
+		t.Fatalf("dns: %v", err)
+	}
+	if report := reg.ValidateResponse(ctx, "verge", http.MethodGet, "dns", resp.Status, resp.Body); report == nil || report.OperationID != "list-dns" {
+		t.Fatalf("expected the scoped call to match list-dns, got %+v", report)
+	}
+	if got := reg.Coverage([]string{"verge"}, false)[0]; got.Exercised != 1 {
+		t.Fatalf("expected the scoped call to count, got %+v", got)
+	}
+}
@@ -0,0 +1,364 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/redact"
+)
+
+const (
+	FixtureRecord = "record"
+	FixtureReplay = "replay"
+)
+
+// ErrNoFixture is returned in replay mode for a call that was never
+// recorded.
+var ErrNoFixture = errors.New("no recorded fixture")
+
+// Fixture holds the recorded responses to one request, in the order they
+// were received, so retried calls replay the same sequence. Paths are
+// relative to the provider's API base.
+type Fixture struct {
+	Provider       string            `json:"provider"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	RequestHeaders map[string]string `json:"requestHeaders,omitempty"`
+	RequestBody    json.RawMessage   `json:"requestBody,omitempty"`
+	Responses      []FixtureResponse `json:"responses"`
+}
+
+// FixtureResponse is one recorded response. Body holds JSON bodies as-is;
+// other bodies are kept as text in BodyText.
+type FixtureResponse struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	BodyText   string            `json:"bodyText,omitempty"`
+	RecordedAt string            `json:"recordedAt"`
+}
+
+// fixtureTransport records provider API traffic to, or replays it from,
+// one JSON file per distinct request under dir/<provider>/.
+type fixtureTransport struct {
+	mode      string
+	dir       string
+	providers map[string]config.ProviderConfig
+	next      http.RoundTripper
+
+	mu      sync.Mutex
+	written map[string]*Fixture
+	cursors map[string]int
+}
+
+func newFixtureTransport(mode, dir string, providers map[string]config.ProviderConfig, next http.RoundTripper) *fixtureTransport {
+	return &fixtureTransport{
+		mode:      mode,
+		dir:       dir,
+		providers: providers,
+		next:      next,
+		written:   make(map[string]*Fixture),
+		cursors:   make(map[string]int),
+	}
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider, path := t.providerFor(req)
+	if provider.ID == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	file := t.fixturePath(provider.ID, req.Method, path, credentialVariant(req.Header, provider), body)
+
+	if t.mode == FixtureReplay {
+		return t.replay(req, file, path)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	secrets := providerSecrets(provider)
+	recorded := FixtureResponse{
+		Status:     resp.StatusCode,
+		Headers:    redactHeaders(resp.Header, secrets),
+		RecordedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	recorded.Body, recorded.BodyText = redactBody(respBody, secrets)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fixture, ok := t.written[file]
+	if !ok {
+		fixture = &Fixture{
+			Provider:       provider.ID,
+			Method:         req.Method,
+			Path:           redactQuery(path),
+			RequestHeaders: redactHeaders(req.Header, secrets),
+		}
+		fixture.RequestBody, _ = redactBody(body, secrets)
+		t.written[file] = fixture
+	}
+	fixture.Responses = append(fixture.Responses, recorded)
+	if err := writeFixture(file, fixture); err != nil {
+		return nil, fmt.Errorf("record fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (t *fixtureTransport) replay(req *http.Request, file, path string) (*http.Response, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoFixture, req.Method, path)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", file, err)
+	}
+	if len(fixture.Responses) == 0 {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoFixture, req.Method, path)
+	}
+
+	t.mu.Lock()
+	i := t.cursors[file]
+	if i < len(fixture.Responses)-1 {
+		t.cursors[file] = i + 1
+	}
+	t.mu.Unlock()
+
+	recorded := fixture.Responses[i]
+	header := make(http.Header, len(recorded.Headers))
+	for k, v := range recorded.Headers {
+		header.Set(k, v)
+	}
+	body := []byte(recorded.BodyText)
+	if len(recorded.Body) > 0 {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, recorded.Body); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", file, err)
+		}
+		body = compact.Bytes()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// providerFor finds the provider whose API base the request targets, and
+// the request path and query relative to that base.
+func (t *fixtureTransport) providerFor(req *http.Request) (config.ProviderConfig, string) {
+	target := req.URL.Scheme + "://" + req.URL.Host + req.URL.EscapedPath()
+	var best config.ProviderConfig
+	for _, p := range t.providers {
+		if p.APIBase != "" && strings.HasPrefix(target, p.APIBase) && len(p.APIBase) > len(best.APIBase) {
+			best = p
+		}
+	}
+	if best.ID == "" {
+		return best, ""
+	}
+	path := ensureLeadingSlash(strings.TrimPrefix(target, best.APIBase))
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.Query().Encode()
+	}
+	return best, path
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixturePath names the file of a request: the method and path for
+// readability, plus a hash of everything that identifies the request.
+func (t *fixtureTransport) fixturePath(providerID, method, path, variant string, body []byte) string {
+	sum := sha256.Sum256([]byte(method + " " + path + "\n" + variant + "\n" + string(body)))
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.SplitN(path, "?", 2)[0], "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return filepath.Join(t.dir, providerID, fmt.Sprintf("%s_%s_%s.json", strings.ToLower(method), name, hex.EncodeToString(sum[:4])))
+}
+
+func writeFixture(file string, fixture *Fixture) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(raw, '\n'), 0o644)
+}
+
+// providerSecrets returns the credential values the registry sends to a
+// provider, so they can be scrubbed wherever they appear.
+func providerSecrets(provider config.ProviderConfig) []string {
+	var out []string
+	for name, value := range provider.Headers {
+		if !redact.SensitiveName.MatchString(name) {
+			continue
+		}
+		if _, credential, ok := strings.Cut(value, " "); ok && credential != "" {
+			value = credential
+		}
+		if len(value) >= 4 {
+			out = append(out, value)
+		}
+	}
+	for _, token := range provider.Profiles {
+		if len(token) >= 4 {
+			out = append(out, token)
+		}
+	}
+	return out
+}
+
+// credentialVariant describes how a request's credentials differ from the
+// provider's configured ones, with those tokens scrubbed so the result does
+// not depend on them. It is empty for requests sent with the configured
+// credentials, which therefore replay under any token; credential profiles
+// are identified by name.
+func credentialVariant(h http.Header, provider config.ProviderConfig) string {
+	secrets := providerSecrets(provider)
+	configured := make(map[string]string, len(provider.Headers))
+	for name, value := range provider.Headers {
+		configured[http.CanonicalHeaderKey(name)] = value
+	}
+	profiles := make(map[string]string, len(provider.Profiles))
+	for name, token := range provider.Profiles {
+		profiles[provider.AuthScheme+token] = name
+	}
+	names := make(map[string]bool)
+	for name := range h {
+		names[name] = true
+	}
+	for name := range configured {
+		names[name] = true
+	}
+	var diffs []string
+	for name := range names {
+		if !redact.SensitiveName.MatchString(name) {
+			continue
+		}
+		value := h.Get(name)
+		if value == configured[name] {
+			continue
+		}
+		if profile, ok := profiles[value]; ok && name == http.CanonicalHeaderKey(provider.AuthHeader) {
+			diffs = append(diffs, name+"=profile:"+profile)
+			continue
+		}
+		diffs = append(diffs, name+"="+scrub(value, secrets))
+	}
+	sort.Strings(diffs)
+	return strings.Join(diffs, "\n")
+}
+
+func scrub(s string, secrets []string) string {
+	return redact.New(secrets...).String(s)
+}
+
+func redactHeaders(h http.Header, secrets []string) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if redact.SensitiveName.MatchString(name) {
+			value = redact.Placeholder
+		}
+		out[name] = scrub(value, secrets)
+	}
+	return out
+}
+
+func redactQuery(path string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	parts := strings.Split(query, "&")
+	for i, part := range parts {
+		if name, _, found := strings.Cut(part, "="); found && redact.SensitiveName.MatchString(name) {
+			parts[i] = name + "=" + redact.Placeholder
+		}
+	}
+	return base + "?" + strings.Join(parts, "&")
+}
+
+// redactBody returns a JSON body with credential members replaced, or a
+// non-JSON body as scrubbed text.
+func redactBody(body []byte, secrets []string) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, scrub(string(body), secrets)
+	}
+	raw, err := json.Marshal(redactValue(value, secrets))
+	if err != nil {
+		return nil, scrub(string(body), secrets)
+	}
+	return raw, ""
+}
+
+func redactValue(v interface{}, secrets []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if _, isString := child.(string); isString && redact.SensitiveName.MatchString(k) {
+				t[k] = redact.Placeholder
+				continue
+			}
+			t[k] = redactValue(child, secrets)
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = redactValue(child, secrets)
+		}
+		return t
+	case string:
+		return scrub(t, secrets)
+	default:
+		return v
+	}
+}
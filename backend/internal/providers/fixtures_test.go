@@ -0,0 +1,107 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func fixtureConfig(mode, dir, apiBase string) config.Config {
+	return config.Config{
+		APIFixtureMode: mode,
+		APIFixtureDir:  dir,
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {
+				ID:         "arvan",
+				APIBase:    apiBase,
+				Domain:     "example.com",
+				AuthHeader: "Authorization",
+				AuthScheme: "apikey ",
+				Headers:    map[string]string{"Authorization": "apikey s3cr3t-token-0123456789"},
+			},
+		},
+	}
+}
+
+func TestFixturesRecordAndReplay(t *testing.T) {
+	calls := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"message":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"domain":"example.com","api_token":"leaked","note":"key s3cr3t-token-0123456789"}]}`))
+	}))
+	dir := t.TempDir()
+
+	reg := NewRegistry(fixtureConfig(FixtureRecord, dir, api.URL))
+	reg.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	live, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err != nil || live.Status != http.StatusOK || !strings.Contains(string(live.Body), "leaked") {
+		t.Fatalf("record mode must pass the real response through, got %d %s: %v", live.Status, live.Body, err)
+	}
+	api.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "arvan", "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one fixture file, got %v", files)
+	}
+	raw, _ := os.ReadFile(files[0])
+	for _, secret := range []string{"s3cr3t-token-0123456789", "leaked", "session=abc"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("fixture leaks %q:\n%s", secret, raw)
+		}
+	}
+
+	replay := NewRegistry(fixtureConfig(FixtureReplay, dir, api.URL))
+	replay.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	resp, err := replay.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err != nil || resp.Status != http.StatusOK || resp.Attempts != 2 {
+		t.Fatalf("expected the recorded 503 then 200, got %d after %d attempts: %v", resp.Status, resp.Attempts, err)
+	}
+	if !strings.Contains(string(resp.Body), `"domain":"example.com"`) {
+		t.Fatalf("unexpected replayed body %s", resp.Body)
+	}
+
+	_, err = replay.Send(context.Background(), "arvan", http.MethodGet, "dns", nil)
+	if !errors.Is(err, ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture for an unrecorded call, got %v", err)
+	}
+}
+
+func TestFixturesCoverPurges(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message":"purged"}`))
+	}))
+	dir := t.TempDir()
+	target := "https://example.com/a.css"
+
+	if _, err := NewRegistry(fixtureConfig(FixtureRecord, dir, api.URL)).Purge(context.Background(), "arvan", target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api.Close()
+
+	replay := NewRegistry(fixtureConfig(FixtureReplay, dir, api.URL))
+	result, err := replay.Purge(context.Background(), "arvan", target)
+	if err != nil {
+		t.Fatalf("expected the purge to replay without the network: %v", err)
+	}
+	if purge := result.(purgeResult); purge.Status != http.StatusOK {
+		t.Fatalf("unexpected replayed purge %+v", purge)
+	}
+	if _, err := replay.Purge(context.Background(), "arvan", "https://example.com/b.css"); !errors.Is(err, ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture for an unrecorded purge, got %v", err)
+	}
+}
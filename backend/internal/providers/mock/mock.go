@@ -0,0 +1,211 @@
+// Package mock serves a provider's OpenAPI paths with example or
+// schema-generated responses, so the API suite can run without real
+// credentials or network access.
+package mock
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// Options configures the behaviour of a mock provider API.
+type Options struct {
+	// AuthHeader must be present on every request, with the value
+	// AuthScheme followed by Token. An empty Token accepts any credential;
+	// an empty AuthHeader disables the check.
+	AuthHeader string
+	AuthScheme string
+	Token      string
+
+	// Latency delays every response.
+	Latency time.Duration
+
+	// ErrorRate is the fraction of requests answered with ErrorStatus
+	// (default 503) instead of the operation's response.
+	ErrorRate   float64
+	ErrorStatus int
+
+	// RateLimit caps accepted requests per second, with bursts of up to
+	// RateBurst; excess requests get 429 with Retry-After. Zero disables it.
+	RateLimit float64
+	RateBurst int
+
+	// Seed makes error injection reproducible.
+	Seed int64
+}
+
+// ProviderOptions returns the auth settings the registry sends for
+// provider: its auth header and scheme, and the token configured in that
+// header.
+func ProviderOptions(provider config.ProviderConfig) Options {
+	if provider.AuthHeader == "" {
+		return Options{}
+	}
+	return Options{
+		AuthHeader: provider.AuthHeader,
+		AuthScheme: provider.AuthScheme,
+		Token:      strings.TrimPrefix(provider.Headers[provider.AuthHeader], provider.AuthScheme),
+	}
+}
+
+// Server answers requests for the operations of one OpenAPI document.
+type Server struct {
+	doc  *openapi.Document
+	opts Options
+
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	tokens float64
+	last   time.Time
+}
+
+// New returns a mock of the API described by doc.
+func New(doc *openapi.Document, opts Options) *Server {
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusServiceUnavailable
+	}
+	if opts.RateBurst < 1 {
+		opts.RateBurst = 1
+	}
+	return &Server{
+		doc:    doc,
+		opts:   opts,
+		rnd:    rand.New(rand.NewSource(opts.Seed)),
+		tokens: float64(opts.RateBurst),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Latency > 0 {
+		timer := time.NewTimer(s.opts.Latency)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthenticated."})
+		return
+	}
+	if wait, ok := s.allow(); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"message": "Too Many Attempts."})
+		return
+	}
+	if s.failNext() {
+		writeJSON(w, s.opts.ErrorStatus, map[string]string{"message": http.StatusText(s.opts.ErrorStatus)})
+		return
+	}
+
+	op, ok := s.doc.FindOperation(r.Method, r.URL.Path)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "No route matches " + r.Method + " " + r.URL.Path})
+		return
+	}
+
+	status, resp := s.doc.SuccessResponse(op.Operation)
+	body, ok := s.doc.ResponseExample(resp)
+	if !ok {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, singlePage(body, r))
+}
+
+// singlePage makes pagination members of a generated list describe one
+// complete page, so clients walking links or meta stop after it. body may
+// share maps with the spec's examples, so it works on a copy.
+func singlePage(body interface{}, r *http.Request) interface{} {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return body
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return body
+	}
+	count := 0
+	if items, ok := obj["data"].([]interface{}); ok {
+		count = len(items)
+	}
+	if links, ok := obj["links"].(map[string]interface{}); ok {
+		self := "http://" + r.Host + r.URL.RequestURI()
+		links["first"], links["last"] = self, self
+		links["prev"], links["next"] = nil, nil
+	}
+	if meta, ok := obj["meta"].(map[string]interface{}); ok {
+		for key, value := range map[string]int{"current_page": 1, "last_page": 1, "from": 1, "to": count, "total": count} {
+			if _, present := meta[key]; present {
+				meta[key] = value
+			}
+		}
+		delete(meta, "next_cursor")
+	}
+	delete(obj, "next_cursor")
+	return obj
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.AuthHeader == "" {
+		return true
+	}
+	value := r.Header.Get(s.opts.AuthHeader)
+	if !strings.HasPrefix(value, s.opts.AuthScheme) {
+		return false
+	}
+	credential := strings.TrimPrefix(value, s.opts.AuthScheme)
+	if s.opts.Token == "" {
+		return credential != ""
+	}
+	return credential == s.opts.Token
+}
+
+// allow takes a token from the bucket, or reports how long until one is
+// available.
+func (s *Server) allow() (time.Duration, bool) {
+	if s.opts.RateLimit <= 0 {
+		return 0, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.last.IsZero() {
+		s.tokens += now.Sub(s.last).Seconds() * s.opts.RateLimit
+		if max := float64(s.opts.RateBurst); s.tokens > max {
+			s.tokens = max
+		}
+	}
+	s.last = now
+	if s.tokens >= 1 {
+		s.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - s.tokens) / s.opts.RateLimit * float64(time.Second)), false
+}
+
+func (s *Server) failNext() bool {
+	if s.opts.ErrorRate <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Float64() < s.opts.ErrorRate
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,142 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+var bundledSpecs = map[string]string{
+	"arvan": "arvancloud-api.yml",
+	"verge": "vergecloud-api.json",
+}
+
+func loadSpec(t *testing.T, providerID string) *openapi.Document {
+	t.Helper()
+	doc, err := openapi.Load(filepath.Join("..", "..", "..", "..", "api", bundledSpecs[providerID]))
+	if err != nil {
+		t.Fatalf("load %s spec: %v", providerID, err)
+	}
+	return doc
+}
+
+// providerOptions returns the options for providerID as configured by
+// config.Load, with token as its credential.
+func providerOptions(providerID, token string) Options {
+	provider := config.Load().Providers[providerID]
+	provider.Headers = map[string]string{provider.AuthHeader: provider.AuthScheme + token}
+	return ProviderOptions(provider)
+}
+
+func get(t *testing.T, url string, header http.Header) (int, []byte, http.Header) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, resp.Header
+}
+
+func TestMockServesSchemaValidResponses(t *testing.T) {
+	for providerID := range bundledSpecs {
+		doc := loadSpec(t, providerID)
+		opts := providerOptions(providerID, "secret")
+		srv := httptest.NewServer(New(doc, opts))
+		header := http.Header{opts.AuthHeader: {opts.AuthScheme + "secret"}}
+
+		for _, op := range doc.Operations() {
+			if op.Method != http.MethodGet {
+				continue
+			}
+			path := op.Path
+			for _, name := range openapi.PathParams(op.Path) {
+				path = strings.ReplaceAll(path, "{"+name+"}", "example.com")
+			}
+			status, body, _ := get(t, srv.URL+path, header)
+			if status < 200 || status >= 300 {
+				t.Fatalf("%s %s: status %d: %s", providerID, op.Operation.OperationID, status, body)
+			}
+			if report := doc.ValidateResponse(op, status, body); !report.Valid {
+				t.Errorf("%s %s: generated response violates its schema: %+v", providerID, op.Operation.OperationID, report.Violations)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func TestMockEnforcesAuth(t *testing.T) {
+	opts := providerOptions("arvan", "secret")
+	srv := httptest.NewServer(New(loadSpec(t, "arvan"), opts))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		value string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"apikey wrong", http.StatusUnauthorized},
+		{"apikey secret", http.StatusOK},
+	} {
+		header := http.Header{}
+		if tc.value != "" {
+			header.Set("Authorization", tc.value)
+		}
+		if status, _, _ := get(t, srv.URL+"/domains", header); status != tc.want {
+			t.Fatalf("Authorization %q: expected %d, got %d", tc.value, tc.want, status)
+		}
+	}
+}
+
+func TestMockUnknownPath(t *testing.T) {
+	srv := httptest.NewServer(New(loadSpec(t, "verge"), Options{}))
+	defer srv.Close()
+	if status, _, _ := get(t, srv.URL+"/v1/nope", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", status)
+	}
+}
+
+func TestMockInjectsFaults(t *testing.T) {
+	doc := loadSpec(t, "verge")
+
+	failing := httptest.NewServer(New(doc, Options{ErrorRate: 1, ErrorStatus: http.StatusBadGateway}))
+	defer failing.Close()
+	if status, _, _ := get(t, failing.URL+"/v1/domains", nil); status != http.StatusBadGateway {
+		t.Fatalf("expected injected 502, got %d", status)
+	}
+
+	limited := httptest.NewServer(New(doc, Options{RateLimit: 1, RateBurst: 2}))
+	defer limited.Close()
+	var statuses []int
+	var retryAfter string
+	for i := 0; i < 3; i++ {
+		status, _, header := get(t, limited.URL+"/v1/domains", nil)
+		statuses = append(statuses, status)
+		retryAfter = header.Get("Retry-After")
+	}
+	if statuses[0] != http.StatusOK || statuses[1] != http.StatusOK || statuses[2] != http.StatusTooManyRequests || retryAfter == "" {
+		t.Fatalf("expected burst of 2 then 429 with Retry-After, got %v (Retry-After %q)", statuses, retryAfter)
+	}
+
+	slow := httptest.NewServer(New(doc, Options{Latency: 50 * time.Millisecond}))
+	defer slow.Close()
+	start := time.Now()
+	get(t, slow.URL+"/v1/domains", nil)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected latency, response took %v", elapsed)
+	}
+}
@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers/mock"
+)
+
+func TestRegistryAgainstMockAPIs(t *testing.T) {
+	specs := map[string]string{"arvan": "arvancloud-api.yml", "verge": "vergecloud-api.json"}
+	bases := map[string]string{"arvan": "", "verge": "/v1"}
+
+	for id, file := range specs {
+		doc, err := openapi.Load(filepath.Join("..", "..", "..", "api", file))
+		if err != nil {
+			t.Fatalf("load %s: %v", id, err)
+		}
+		provider := config.Load().Providers[id]
+		provider.Domain = "example.com"
+		provider.Headers = map[string]string{provider.AuthHeader: provider.AuthScheme + "secret"}
+		api := httptest.NewServer(mock.New(doc, mock.ProviderOptions(provider)))
+		defer api.Close()
+		provider.APIBase = api.URL + bases[id]
+
+		reg := NewRegistry(config.Config{
+			Providers: map[string]config.ProviderConfig{id: provider},
+		})
+		reg.SetSpec(id, doc)
+
+		for _, resource := range []string{"domains", "domain-details", "dns", "caching"} {
+			resp, err := reg.Send(context.Background(), id, http.MethodGet, resource, nil)
+			if err != nil || resp.Status != http.StatusOK {
+				t.Fatalf("%s %s: %d %v", id, resource, resp.Status, err)
+			}
+			if report := reg.ValidateResponse(context.Background(), id, http.MethodGet, resource, resp.Status, resp.Body); report == nil || !report.Valid || report.Skipped != "" {
+				t.Fatalf("%s %s: unexpected validation %+v", id, resource, report)
+			}
+		}
+
+		if list, err := reg.CallAll(context.Background(), id, "dns", 0); err != nil || len(list.Items) == 0 {
+			t.Fatalf("%s: expected DNS records from the mock, got %d items: %v", id, len(list.Items), err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultListLimit caps the items CallAll collects when no limit is given.
+	DefaultListLimit = 1000
+	// maxPages stops a walk whose pagination never terminates.
+	maxPages = 100
+	perPage  = 100
+)
+
+// listResources are the resources whose responses are paginated lists.
+var listResources = map[string]bool{
+	"domains": true,
+	"dns":     true,
+}
+
+// IsListResource reports whether resource returns a paginated list that
+// CallAll can walk.
+func IsListResource(resource string) bool {
+	return listResources[strings.Trim(strings.TrimSpace(resource), "/")]
+}
+
+// ListResponse is the outcome of walking every page of a list resource.
+type ListResponse struct {
+	Items []json.RawMessage
+	// Total is the provider-reported item count, or the number of items
+	// collected when the provider reports none.
+	Total     int
+	Pages     int
+	Truncated bool
+	Status    int
+	Attempts  int
+	QueueWait time.Duration
+	// LastPage is the raw body of the last page fetched.
+	LastPage []byte
+}
+
+// pageEnvelope covers the list shapes the providers use: a data array with
+// either Laravel-style links/meta (page, per_page, links.next) or a cursor.
+type pageEnvelope struct {
+	Data  json.RawMessage `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Meta       json.RawMessage `json:"meta"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+type pageMeta struct {
+	Total       *int   `json:"total"`
+	CurrentPage int    `json:"current_page"`
+	LastPage    int    `json:"last_page"`
+	NextCursor  string `json:"next_cursor"`
+}
+
+// CallAll GETs resource and follows its pagination until the last page or
+// until limit items are collected (DefaultListLimit when limit <= 0). Each
+// page is a separate call with its own timeout, retries and rate limiting.
+// A non-2xx page ends the walk with that status and the items so far.
+func (r *Registry) CallAll(ctx context.Context, providerID, resource string, limit int) (ListResponse, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	provider, err := r.Provider(ctx, providerID)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	endpoint, err := resolveEndpoint(provider, resource)
+	if err != nil {
+		return ListResponse{}, err
+	}
+	page, err := url.Parse(ensureLeadingSlash(endpoint))
+	if err != nil {
+		return ListResponse{}, err
+	}
+	query := page.Query()
+	query.Set("per_page", strconv.Itoa(perPage))
+	page.RawQuery = query.Encode()
+
+	timeout := r.Timeout(providerID, resource)
+	var out ListResponse
+	seen := make(map[string]bool)
+	for page != nil && !seen[page.String()] {
+		seen[page.String()] = true
+
+		resp, err := r.send(ctx, providerID, http.MethodGet, page.String(), timeout, nil)
+		out.Status = resp.Status
+		out.Attempts += resp.Attempts
+		out.QueueWait += resp.QueueWait
+		if err != nil {
+			return out, err
+		}
+		out.LastPage = resp.Body
+		if resp.Status < 200 || resp.Status >= 300 {
+			return out, nil
+		}
+		out.Pages++
+
+		var env pageEnvelope
+		var items []json.RawMessage
+		if err := json.Unmarshal(resp.Body, &env); err != nil || json.Unmarshal(env.Data, &items) != nil {
+			return out, fmt.Errorf("resource %s is not a list", resource)
+		}
+		out.Items = append(out.Items, items...)
+
+		var meta pageMeta
+		_ = json.Unmarshal(env.Meta, &meta)
+		if meta.Total != nil {
+			out.Total = *meta.Total
+		}
+
+		if len(out.Items) >= limit {
+			out.Truncated = len(out.Items) > limit || hasNextPage(env, meta)
+			out.Items = out.Items[:limit]
+			break
+		}
+		if out.Pages >= maxPages {
+			out.Truncated = hasNextPage(env, meta)
+			break
+		}
+		if page, err = nextPage(provider.APIBase, page, env, meta); err != nil {
+			return out, err
+		}
+	}
+
+	if out.Total < len(out.Items) {
+		out.Total = len(out.Items)
+	}
+	return out, nil
+}
+
+func hasNextPage(env pageEnvelope, meta pageMeta) bool {
+	return env.Links.Next != "" || env.NextCursor != "" || meta.NextCursor != "" ||
+		(meta.LastPage > 0 && meta.CurrentPage < meta.LastPage)
+}
+
+// nextPage returns the path of the page after current, or nil on the last
+// page. A links.next URL is only followed when it stays under the provider's
+// API base, so credentials are never sent elsewhere.
+func nextPage(apiBase string, current *url.URL, env pageEnvelope, meta pageMeta) (*url.URL, error) {
+	if env.Links.Next != "" {
+		if !strings.HasPrefix(env.Links.Next, apiBase+"/") {
+			return nil, fmt.Errorf("next page link outside API base: %s", env.Links.Next)
+		}
+		return url.Parse(strings.TrimPrefix(env.Links.Next, apiBase))
+	}
+
+	next := *current
+	query := next.Query()
+	switch cursor := firstNonEmpty(meta.NextCursor, env.NextCursor); {
+	case cursor != "":
+		query.Set("cursor", cursor)
+	case meta.LastPage > 0 && meta.CurrentPage < meta.LastPage:
+		query.Set("page", strconv.Itoa(meta.CurrentPage+1))
+	default:
+		return nil, nil
+	}
+	next.RawQuery = query.Encode()
+	return &next, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
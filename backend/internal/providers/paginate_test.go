@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func newListRegistry(t *testing.T, handler func(base string) http.HandlerFunc) *Registry {
+	t.Helper()
+	var api *httptest.Server
+	api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(api.URL)(w, r)
+	}))
+	t.Cleanup(api.Close)
+
+	return NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+	})
+}
+
+func TestCallAllFollowsNextLinks(t *testing.T) {
+	reg := newListRegistry(t, func(base string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			page = max(page, 1)
+			next := "null"
+			if page < 3 {
+				next = fmt.Sprintf("%q", fmt.Sprintf("%s%s?page=%d", base, r.URL.Path, page+1))
+			}
+			fmt.Fprintf(w, `{"data":[{"page":%d},{"page":%d}],"links":{"next":%s},"meta":{"total":6}}`, page, page, next)
+		}
+	})
+
+	list, err := reg.CallAll(context.Background(), "arvan", "domains", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Pages != 3 || len(list.Items) != 6 || list.Total != 6 || list.Truncated {
+		t.Fatalf("expected 6 items over 3 pages, got %+v", list)
+	}
+}
+
+func TestCallAllPageNumbersAndLimit(t *testing.T) {
+	reg := newListRegistry(t, func(string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				page = "1"
+			}
+			fmt.Fprintf(w, `{"data":[1,2,3],"meta":{"current_page":%s,"last_page":5,"total":15}}`, page)
+		}
+	})
+
+	list, err := reg.CallAll(context.Background(), "arvan", "dns", 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Pages != 3 || len(list.Items) != 7 || list.Total != 15 || !list.Truncated {
+		t.Fatalf("expected 7 of 15 items after 3 pages, got %+v", list)
+	}
+}
+
+func TestCallAllCursor(t *testing.T) {
+	reg := newListRegistry(t, func(string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("cursor") == "" {
+				fmt.Fprint(w, `{"data":["a"],"meta":{"next_cursor":"abc"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":["b"],"meta":{}}`)
+		}
+	})
+
+	list, err := reg.CallAll(context.Background(), "arvan", "domains", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Pages != 2 || list.Total != 2 {
+		t.Fatalf("expected 2 items over 2 pages, got %+v", list)
+	}
+}
+
+func TestCallAllRejectsForeignNextLink(t *testing.T) {
+	reg := newListRegistry(t, func(string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":[1],"links":{"next":"https://elsewhere.example/domains?page=2"}}`)
+		}
+	})
+
+	if _, err := reg.CallAll(context.Background(), "arvan", "domains", 0); err == nil {
+		t.Fatal("expected error for next link outside the API base")
+	}
+}
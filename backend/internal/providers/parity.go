@@ -0,0 +1,397 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// parityResource is a domain-scoped resource and the spec path under which
+// each provider serves it.
+type parityResource struct {
+	name     string
+	prefixes map[string]string
+}
+
+var parityResources = []parityResource{
+	{"DNS records", map[string]string{"arvan": "/domains/{domain}/dns-records", "verge": "/v1/dns/{domain}/records"}},
+	{"Page rules", map[string]string{"arvan": "/domains/{domain}/page-rules", "verge": "/v1/page-rules/{domain}"}},
+	{"Load balancers", map[string]string{"arvan": "/domains/{domain}/load-balancers", "verge": "/v1/load-balancers/{domain}"}},
+	{"Rate limiting", map[string]string{"arvan": "/domains/{domain}/rate-limit", "verge": "/v1/rate-limit/{domain}"}},
+	{"DDoS protection", map[string]string{"arvan": "/domains/{domain}/ddos", "verge": "/v1/ddos/{domain}"}},
+	{"Health checks", map[string]string{"arvan": "/domains/{domain}/health-checks", "verge": "/v1/health-checks/{domain}"}},
+	{"Log forwarders", map[string]string{"arvan": "/domains/{domain}/log-forwarders", "verge": "/v1/log-forwarders/{domain}"}},
+	{"Metric exporters", map[string]string{"arvan": "/domains/{domain}/metric-exporters", "verge": "/v1/metric-exporters/{domain}"}},
+	{"Firewall", map[string]string{"arvan": "/domains/{domain}/firewall", "verge": "/v1/firewall/{domain}"}},
+	{"WAF", map[string]string{"arvan": "/domains/{domain}/waf", "verge": "/v1/waf/{domain}"}},
+	{"SSL/TLS", map[string]string{"arvan": "/domains/{domain}/ssl", "verge": "/v1/ssl/{domain}"}},
+	{"Caching", map[string]string{"arvan": "/domains/{domain}/caching", "verge": "/v1/caching/{domain}"}},
+	{"Acceleration", map[string]string{"arvan": "/domains/{domain}/acceleration", "verge": "/v1/acceleration/{domain}"}},
+	{"Custom pages", map[string]string{"arvan": "/domains/{domain}/custom-pages", "verge": "/v1/custom-page/{domain}"}},
+	{"Transport layer proxies", map[string]string{"arvan": "/domains/{domain}/transport-layer-proxies", "verge": "/v1/transport-layer-proxy/{domain}"}},
+}
+
+// paritySuffixes maps sub-paths that differ only in naming onto one form.
+var paritySuffixes = map[string]string{
+	"/actions/reprioritize": "/reprioritize",
+	"/dnssec/actions":       "/dnssec",
+	"/sec":                  "/dnssec",
+}
+
+// ParityReport compares the OpenAPI specs of two providers.
+type ParityReport struct {
+	Providers  []string         `json:"providers"`
+	Categories []CategoryParity `json:"categories"`
+	Resources  []ResourceParity `json:"resources"`
+}
+
+// CategoryParity counts each provider's operations in a checklist category.
+type CategoryParity struct {
+	Category   string         `json:"category"`
+	Operations map[string]int `json:"operations"`
+}
+
+// ResourceParity aligns the operations of one resource. Paths are relative
+// to the resource, with path parameters written as {id}.
+type ResourceParity struct {
+	Resource string                       `json:"resource"`
+	Shared   []SharedOperation            `json:"shared"`
+	Unique   map[string][]ParityOperation `json:"unique,omitempty"`
+}
+
+type ParityOperation struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operationId"`
+}
+
+// SharedOperation is an operation both providers offer, with the query
+// parameters and response fields on which they differ.
+type SharedOperation struct {
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	OperationIDs map[string]string `json:"operationIds"`
+	Parameters   []ParityDiff      `json:"parameters,omitempty"`
+	Fields       []ParityDiff      `json:"fields,omitempty"`
+}
+
+// ParityDiff is a parameter or field that only one provider has (OnlyIn),
+// or that both have but declare differently (Detail).
+type ParityDiff struct {
+	Name   string `json:"name"`
+	OnlyIn string `json:"onlyIn,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ErrNoParityResources is returned by Parity for a provider whose spec
+// paths are not mapped in parityResources.
+var ErrNoParityResources = errors.New("no parity resources mapped")
+
+// Parity compares the loaded specs of providers a and b.
+func (r *Registry) Parity(a, b string) (ParityReport, error) {
+	for _, id := range []string{a, b} {
+		if _, ok := parityResources[0].prefixes[id]; !ok {
+			return ParityReport{}, fmt.Errorf("%w for %s", ErrNoParityResources, id)
+		}
+	}
+	docs := make(map[string]*openapi.Document, 2)
+	for _, id := range []string{a, b} {
+		doc := r.Spec(id)
+		if doc == nil {
+			return ParityReport{}, fmt.Errorf("no OpenAPI spec loaded for %s", id)
+		}
+		docs[id] = doc
+	}
+
+	report := ParityReport{Providers: []string{a, b}}
+	report.Categories = categoryParity(docs, a, b)
+	for _, res := range parityResources {
+		report.Resources = append(report.Resources, resourceParity(res, docs, a, b))
+	}
+	return report, nil
+}
+
+func categoryParity(docs map[string]*openapi.Document, a, b string) []CategoryParity {
+	counts := make(map[string]map[string]int)
+	for _, id := range []string{a, b} {
+		for _, op := range docs[id].Operations() {
+			category := OperationCategory(op.Operation)
+			if counts[category] == nil {
+				counts[category] = map[string]int{a: 0, b: 0}
+			}
+			counts[category][id]++
+		}
+	}
+
+	var out []CategoryParity
+	for _, category := range checklistOrder {
+		if c, ok := counts[category]; ok {
+			out = append(out, CategoryParity{Category: category, Operations: c})
+		}
+	}
+	return out
+}
+
+func resourceParity(res parityResource, docs map[string]*openapi.Document, a, b string) ResourceParity {
+	ops := map[string]map[string]openapi.OperationRef{
+		a: resourceOperations(docs[a], res.prefixes[a]),
+		b: resourceOperations(docs[b], res.prefixes[b]),
+	}
+
+	keys := make(map[string]bool)
+	for _, byKey := range ops {
+		for key := range byKey {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return parityLess(sorted[i], sorted[j]) })
+
+	out := ResourceParity{Resource: res.name, Shared: []SharedOperation{}}
+	for _, key := range sorted {
+		method, path, _ := strings.Cut(key, " ")
+		opA, inA := ops[a][key]
+		opB, inB := ops[b][key]
+		switch {
+		case inA && inB:
+			out.Shared = append(out.Shared, SharedOperation{
+				Method:       method,
+				Path:         path,
+				OperationIDs: map[string]string{a: opA.Operation.OperationID, b: opB.Operation.OperationID},
+				Parameters:   parameterDiffs(opA, opB, a, b),
+				Fields:       fieldDiffs(docs[a], docs[b], opA, opB, a, b),
+			})
+		default:
+			id, op := a, opA
+			if inB {
+				id, op = b, opB
+			}
+			if out.Unique == nil {
+				out.Unique = make(map[string][]ParityOperation)
+			}
+			out.Unique[id] = append(out.Unique[id], ParityOperation{Method: method, Path: path, OperationID: op.Operation.OperationID})
+		}
+	}
+	return out
+}
+
+// resourceOperations indexes the operations under prefix by method and
+// normalised sub-path, e.g. "GET /{id}".
+func resourceOperations(doc *openapi.Document, prefix string) map[string]openapi.OperationRef {
+	out := make(map[string]openapi.OperationRef)
+	if prefix == "" {
+		return out
+	}
+	for _, op := range doc.Operations() {
+		if op.Path != prefix && !strings.HasPrefix(op.Path, prefix+"/") {
+			continue
+		}
+		out[op.Method+" "+paritySuffix(strings.TrimPrefix(op.Path, prefix))] = op
+	}
+	return out
+}
+
+func paritySuffix(suffix string) string {
+	segments := strings.Split(suffix, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = "{id}"
+		}
+	}
+	suffix = strings.Join(segments, "/")
+	if alias, ok := paritySuffixes[suffix]; ok {
+		suffix = alias
+	}
+	if suffix == "" {
+		return "/"
+	}
+	return suffix
+}
+
+var parityMethodOrder = map[string]int{
+	http.MethodGet: 0, http.MethodPost: 1, http.MethodPut: 2, http.MethodPatch: 3, http.MethodDelete: 4,
+}
+
+// parityLess orders "METHOD /path" keys by path, then method.
+func parityLess(x, y string) bool {
+	mx, px, _ := strings.Cut(x, " ")
+	my, py, _ := strings.Cut(y, " ")
+	if px != py {
+		return px < py
+	}
+	return parityMethodOrder[mx] < parityMethodOrder[my]
+}
+
+func parameterDiffs(opA, opB openapi.OperationRef, a, b string) []ParityDiff {
+	paramsA, paramsB := queryParameters(opA), queryParameters(opB)
+	var out []ParityDiff
+	for _, name := range unionKeys(paramsA, paramsB) {
+		pa, inA := paramsA[name]
+		pb, inB := paramsB[name]
+		switch {
+		case !inB:
+			out = append(out, ParityDiff{Name: name, OnlyIn: a})
+		case !inA:
+			out = append(out, ParityDiff{Name: name, OnlyIn: b})
+		case pa.Required != pb.Required:
+			required := a
+			if pb.Required {
+				required = b
+			}
+			out = append(out, ParityDiff{Name: name, Detail: "required only in " + required})
+		default:
+			if ta, tb := parameterType(pa), parameterType(pb); ta != "" && tb != "" && nonNull(ta) != nonNull(tb) {
+				out = append(out, ParityDiff{Name: name, Detail: fmt.Sprintf("%s in %s, %s in %s", ta, a, tb, b)})
+			}
+		}
+	}
+	return out
+}
+
+func queryParameters(op openapi.OperationRef) map[string]*openapi.Parameter {
+	out := make(map[string]*openapi.Parameter)
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			out[p.Name] = p
+		}
+	}
+	return out
+}
+
+func parameterType(p *openapi.Parameter) string {
+	if p.Schema == nil {
+		return ""
+	}
+	return strings.Join(p.Schema.Type, "|")
+}
+
+func fieldDiffs(docA, docB *openapi.Document, opA, opB openapi.OperationRef, a, b string) []ParityDiff {
+	fieldsA := docA.SchemaFields(successSchema(docA, opA.Operation))
+	fieldsB := docB.SchemaFields(successSchema(docB, opB.Operation))
+	var out []ParityDiff
+	for _, name := range unionKeys(fieldsA, fieldsB) {
+		ta, inA := fieldsA[name]
+		tb, inB := fieldsB[name]
+		switch {
+		case !inB:
+			out = append(out, ParityDiff{Name: name, OnlyIn: a})
+		case !inA:
+			out = append(out, ParityDiff{Name: name, OnlyIn: b})
+		case nonNull(ta) != nonNull(tb):
+			out = append(out, ParityDiff{Name: name, Detail: fmt.Sprintf("%s in %s, %s in %s", ta, a, tb, b)})
+		}
+	}
+	return out
+}
+
+// nonNull drops "null" from a field type, so that nullability alone does
+// not count as a difference.
+func nonNull(types string) string {
+	var kept []string
+	for _, t := range strings.Split(types, "|") {
+		if t != "null" {
+			kept = append(kept, t)
+		}
+	}
+	return strings.Join(kept, "|")
+}
+
+// successSchema returns the response schema op declares for success.
+func successSchema(doc *openapi.Document, op *openapi.Operation) *openapi.Schema {
+	for _, status := range []int{http.StatusOK, http.StatusCreated, http.StatusAccepted} {
+		if schema := doc.ResponseSchema(op, status); schema != nil {
+			return schema
+		}
+	}
+	return nil
+}
+
+func unionKeys[V any](x, y map[string]V) []string {
+	seen := make(map[string]bool, len(x)+len(y))
+	for k := range x {
+		seen[k] = true
+	}
+	for k := range y {
+		seen[k] = true
+	}
+	out := make([]string, 0, len(seen))
+	for k := range seen {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Markdown renders the report as a document for the checklist's
+// "Which features exist in both providers?" question.
+func (p ParityReport) Markdown() string {
+	a, b := p.Providers[0], p.Providers[1]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Feature parity: %s vs %s\n\n", a, b)
+
+	sb.WriteString("## Operations per checklist category\n\n")
+	fmt.Fprintf(&sb, "| Category | %s | %s |\n|---|---:|---:|\n", a, b)
+	for _, c := range p.Categories {
+		fmt.Fprintf(&sb, "| %s | %d | %d |\n", c.Category, c.Operations[a], c.Operations[b])
+	}
+
+	for _, res := range p.Resources {
+		fmt.Fprintf(&sb, "\n## %s\n\n", res.Resource)
+		if len(res.Shared) > 0 {
+			fmt.Fprintf(&sb, "| Operation | %s | %s | Differences |\n|---|---|---|---|\n", a, b)
+			for _, op := range res.Shared {
+				fmt.Fprintf(&sb, "| `%s %s` | `%s` | `%s` | %s |\n", op.Method, op.Path,
+					op.OperationIDs[a], op.OperationIDs[b], diffSummary(op))
+			}
+		}
+		for _, id := range p.Providers {
+			if len(res.Unique[id]) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "\nOnly in %s:\n\n", id)
+			for _, op := range res.Unique[id] {
+				fmt.Fprintf(&sb, "- `%s %s` (`%s`)\n", op.Method, op.Path, op.OperationID)
+			}
+		}
+		for _, op := range res.Shared {
+			if len(op.Parameters)+len(op.Fields) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "\n### `%s %s`\n\n", op.Method, op.Path)
+			writeDiffs(&sb, "parameter", op.Parameters)
+			writeDiffs(&sb, "field", op.Fields)
+		}
+	}
+	return sb.String()
+}
+
+func diffSummary(op SharedOperation) string {
+	var parts []string
+	if n := len(op.Parameters); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d parameter(s)", n))
+	}
+	if n := len(op.Fields); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d field(s)", n))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func writeDiffs(sb *strings.Builder, kind string, diffs []ParityDiff) {
+	for _, d := range diffs {
+		if d.OnlyIn != "" {
+			fmt.Fprintf(sb, "- %s `%s` only in %s\n", kind, d.Name, d.OnlyIn)
+			continue
+		}
+		fmt.Fprintf(sb, "- %s `%s`: %s\n", kind, d.Name, d.Detail)
+	}
+}
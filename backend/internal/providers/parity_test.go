@@ -0,0 +1,167 @@
+package providers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+const parityArvanSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Arvan", "version": "4.0"},
+  "paths": {
+    "/domains/{domain}/dns-records": {
+      "get": {
+        "operationId": "dns-records.index",
+        "tags": ["DNS Management"],
+        "parameters": [
+          {"name": "page", "in": "query", "schema": {"type": "integer"}},
+          {"name": "type", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {"200": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/Record"}}}
+        }}}}}
+      }
+    },
+    "/domains/{domain}/dns-records/export": {
+      "get": {"operationId": "dns-records.export", "tags": ["DNS Management"]}
+    },
+    "/domains/{domain}/firewall/actions/reprioritize": {
+      "post": {"operationId": "firewall.reprioritize", "tags": ["Firewall"]}
+    }
+  },
+  "components": {"schemas": {"Record": {
+    "type": "object",
+    "properties": {"name": {"type": "string"}, "ttl": {"type": "integer"}, "value": {"type": "object"}}
+  }}}
+}`
+
+const parityVergeSpec = `{
+  "openapi": "3.1.0",
+  "info": {"title": "Verge", "version": "1"},
+  "paths": {
+    "/v1/dns/{domain}/records": {
+      "get": {
+        "operationId": "get-dns-records",
+        "tags": ["DNS"],
+        "parameters": [
+          {"name": "page", "in": "query", "required": true, "schema": {"type": "integer"}},
+          {"name": "search", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {"200": {"content": {"application/json": {"schema": {
+          "type": "object",
+          "properties": {"data": {"type": ["array", "null"], "items": {
+            "type": "object",
+            "properties": {"name": {"type": "string"}, "ttl": {"type": "string"}}
+          }}}
+        }}}}}
+      }
+    },
+    "/v1/firewall/{domain}/reprioritize": {
+      "post": {"operationId": "reprioritize-firewall", "tags": ["Firewall"]}
+    },
+    "/v1/organizations/": {
+      "get": {"operationId": "get-organization", "tags": ["Organization"]}
+    }
+  }
+}`
+
+func parityRegistry(t *testing.T) *Registry {
+	t.Helper()
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan"},
+			"verge": {ID: "verge"},
+		},
+	})
+	for id, raw := range map[string]string{"arvan": parityArvanSpec, "verge": parityVergeSpec} {
+		doc, err := openapi.Parse([]byte(raw), ".json")
+		if err != nil {
+			t.Fatalf("parse %s: %v", id, err)
+		}
+		reg.SetSpec(id, doc)
+	}
+	return reg
+}
+
+func TestParityAlignsResources(t *testing.T) {
+	report, err := parityRegistry(t).Parity("arvan", "verge")
+	if err != nil {
+		t.Fatalf("parity: %v", err)
+	}
+
+	resources := make(map[string]ResourceParity)
+	for _, res := range report.Resources {
+		resources[res.Resource] = res
+	}
+
+	dns := resources["DNS records"]
+	if len(dns.Shared) != 1 || dns.Shared[0].Method != "GET" || dns.Shared[0].Path != "/" {
+		t.Fatalf("expected the record list to be shared, got %+v", dns.Shared)
+	}
+	if got := dns.Unique["arvan"]; len(got) != 1 || got[0].Path != "/export" {
+		t.Fatalf("expected export to be unique to arvan, got %+v", dns.Unique)
+	}
+
+	list := dns.Shared[0]
+	wantParams := map[string]ParityDiff{
+		"page":   {Name: "page", Detail: "required only in verge"},
+		"search": {Name: "search", OnlyIn: "verge"},
+		"type":   {Name: "type", OnlyIn: "arvan"},
+	}
+	if len(list.Parameters) != len(wantParams) {
+		t.Fatalf("unexpected parameter diffs: %+v", list.Parameters)
+	}
+	for _, d := range list.Parameters {
+		if wantParams[d.Name] != d {
+			t.Fatalf("unexpected parameter diff: %+v", d)
+		}
+	}
+
+	fields := make(map[string]ParityDiff)
+	for _, d := range list.Fields {
+		fields[d.Name] = d
+	}
+	if _, ok := fields["data"]; ok {
+		t.Fatalf("nullability alone should not differ: %+v", fields["data"])
+	}
+	if fields["data[].value"].OnlyIn != "arvan" || fields["data[].ttl"].Detail != "integer in arvan, string in verge" {
+		t.Fatalf("unexpected field diffs: %+v", list.Fields)
+	}
+
+	firewall := resources["Firewall"]
+	if len(firewall.Shared) != 1 || firewall.Shared[0].Path != "/reprioritize" {
+		t.Fatalf("expected reprioritize to align, got %+v", firewall)
+	}
+
+	for _, c := range report.Categories {
+		if c.Category == CategoryOrganization && (c.Operations["arvan"] != 0 || c.Operations["verge"] != 1) {
+			t.Fatalf("unexpected organization counts: %+v", c)
+		}
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"# Feature parity: arvan vs verge", "| `GET /` | `dns-records.index` | `get-dns-records` |", "- `GET /export` (`dns-records.export`)", "parameter `search` only in verge"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown misses %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestParityRequiresSpecs(t *testing.T) {
+	reg := NewRegistry(config.Config{Providers: map[string]config.ProviderConfig{"arvan": {ID: "arvan"}}})
+	if _, err := reg.Parity("arvan", "verge"); err == nil {
+		t.Fatal("expected error without specs")
+	}
+}
+
+func TestParityRejectsUnmappedProviders(t *testing.T) {
+	reg := NewRegistry(config.Config{})
+	if _, err := reg.Parity("arvan", "cloudflare"); !errors.Is(err, ErrNoParityResources) {
+		t.Fatalf("expected ErrNoParityResources, got %v", err)
+	}
+}
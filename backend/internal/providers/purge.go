@@ -2,6 +2,7 @@ package providers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,30 +10,147 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
 )
 
 type purgeResult struct {
-	Status int         `json:"status"`
-	Data   interface{} `json:"data"`
+	Status    int         `json:"status"`
+	Data      interface{} `json:"data"`
+	Attempts  int         `json:"attempts,omitempty"`
+	QueueWait int64       `json:"queueWait,omitempty"`
+}
+
+// purgeCall is a purge request relative to the provider's API base.
+type purgeCall struct {
+	path string
+	body map[string]interface{}
 }
 
+// purgeClient serves purges made without a registry, and Cloudflare's,
+// which has no provider configuration. It has no global timeout; calls are
+// bounded by their context.
+var purgeClient = &http.Client{}
+
 func ExecutePurge(provider, targetURL string) (interface{}, error) {
+	return ExecutePurgeContext(context.Background(), provider, targetURL)
+}
+
+// ExecutePurgeContext purges targetURL on provider. The call is aborted when
+// ctx is done; a ctx without deadline gets DefaultTimeout.
+func ExecutePurgeContext(ctx context.Context, provider, targetURL string) (interface{}, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
 	switch provider {
 	case "cloudflare":
-		return purgeCloudflare(targetURL)
+		return purgeCloudflare(ctx, targetURL)
 	case "arvan", "arvancloud":
-		return purgeArvan(targetURL)
+		return purgeArvan(ctx, envProvider("ARVAN", "https://napi.arvancloud.ir/cdn/4.0", "Authorization", "apikey "), targetURL)
 	case "verge", "vergecloud":
-		return purgeVerge(targetURL)
+		return purgeVerge(ctx, envProvider("VERGE", "https://api.vergecloud.com/v1", "X-API-Key", ""), targetURL)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", provider)
 	}
 }
 
-func purgeCloudflare(target string) (interface{}, error) {
+// Purge is ExecutePurgeContext bounded by the provider's "purge" timeout.
+// Configured providers purge through the registry like any other API call,
+// so the rate limiter, retry policy and fixtures apply, with the domain and
+// credential profile selected by ctx's Scope.
+func (r *Registry) Purge(ctx context.Context, provider, targetURL string) (interface{}, error) {
+	id := CanonicalID(provider)
+	timeout := r.Timeout(id, "purge")
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, ok := r.configs[id]; !ok {
+		return ExecutePurgeContext(ctx, provider, targetURL)
+	}
+	cfg, err := r.Provider(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var call purgeCall
+	_, domain, _, ok := purgeCredentials(cfg)
+	switch id {
+	case "arvan":
+		if !ok {
+			return nil, errors.New("arvancloud token/domain missing on server")
+		}
+		call = arvanPurge(domain, targetURL)
+	case "verge":
+		if !ok {
+			return nil, errors.New("vergecloud token/domain missing on server")
+		}
+		call = vergePurge(domain, targetURL)
+	default:
+		return ExecutePurgeContext(ctx, provider, targetURL)
+	}
+
+	data, err := json.Marshal(call.body)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	resp, err := r.sendWithRetry(ctx, r.retry, id, http.MethodPost, call.path, timeout, headers, data)
+	if resp.Attempts > 0 {
+		r.recordCoverage(ctx, id, http.MethodPost, call.path, resp.Status, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := newPurgeResult(resp.Status, resp.Body)
+	result.Attempts = resp.Attempts
+	result.QueueWait = resp.QueueWait.Milliseconds()
+	return result, nil
+}
+
+// envProvider reads a provider's purge settings straight from the
+// environment, for callers without a registry.
+func envProvider(prefix, defaultBase, authHeader, authScheme string) config.ProviderConfig {
+	return config.ProviderConfig{
+		APIBase:    strings.TrimRight(envOr(prefix+"_API_BASE", defaultBase), "/"),
+		Domain:     os.Getenv(prefix + "_DOMAIN"),
+		AuthHeader: authHeader,
+		AuthScheme: authScheme,
+		Headers:    map[string]string{authHeader: authScheme + config.SecretEnv(prefix+"_TOKEN")},
+	}
+}
+
+// purgeCredentials returns the provider's base URL, domain and auth
+// header, or false when any of them is missing.
+func purgeCredentials(provider config.ProviderConfig) (string, string, map[string]string, bool) {
+	auth := provider.Headers[provider.AuthHeader]
+	if provider.APIBase == "" || provider.Domain == "" || strings.TrimPrefix(auth, provider.AuthScheme) == "" {
+		return "", "", nil, false
+	}
+	headers := map[string]string{
+		provider.AuthHeader: auth,
+		"Content-Type":      "application/json",
+	}
+	return strings.TrimRight(provider.APIBase, "/"), provider.Domain, headers, true
+}
+
+// CanonicalID maps the provider names accepted by purge, such as
+// "arvancloud", to registry IDs.
+func CanonicalID(provider string) string {
+	switch provider {
+	case "arvancloud":
+		return "arvan"
+	case "vergecloud":
+		return "verge"
+	default:
+		return provider
+	}
+}
+
+func purgeCloudflare(ctx context.Context, target string) (interface{}, error) {
 	zone := os.Getenv("CF_ZONE_ID")
-	token := os.Getenv("CF_API_TOKEN")
+	token := config.SecretEnv("CF_API_TOKEN")
 	if zone == "" || token == "" {
 		return nil, errors.New("cloudflare token/zone missing on server")
 	}
@@ -42,54 +160,54 @@ func purgeCloudflare(target string) (interface{}, error) {
 		"Content-Type":  "application/json",
 	}
 	body := map[string]interface{}{"files": []string{target}}
-	return performJSONRequest(endpoint, headers, body)
+	return performJSONRequest(ctx, endpoint, headers, body)
 }
 
-func purgeArvan(target string) (interface{}, error) {
-	base := strings.TrimRight(envOr("ARVAN_API_BASE", "https://napi.arvancloud.ir/cdn/4.0"), "/")
-	domain := os.Getenv("ARVAN_DOMAIN")
-	token := os.Getenv("ARVAN_TOKEN")
-	if base == "" || domain == "" || token == "" {
+func purgeArvan(ctx context.Context, provider config.ProviderConfig, target string) (interface{}, error) {
+	base, domain, headers, ok := purgeCredentials(provider)
+	if !ok {
 		return nil, errors.New("arvancloud token/domain missing on server")
 	}
-	endpoint := fmt.Sprintf("%s/domains/%s/caching/purge", base, domain)
-	headers := map[string]string{
-		"Authorization": "apikey " + token,
-		"Content-Type":  "application/json",
-	}
-	body := map[string]interface{}{
-		"purge":      "individual",
-		"purge_urls": []string{target},
+	call := arvanPurge(domain, target)
+	return performJSONRequest(ctx, base+call.path, headers, call.body)
+}
+
+func arvanPurge(domain, target string) purgeCall {
+	return purgeCall{
+		path: fmt.Sprintf("/domains/%s/caching/purge", domain),
+		body: map[string]interface{}{
+			"purge":      "individual",
+			"purge_urls": []string{target},
+		},
 	}
-	return performJSONRequest(endpoint, headers, body)
 }
 
-func purgeVerge(target string) (interface{}, error) {
-	base := strings.TrimRight(envOr("VERGE_API_BASE", "https://api.vergecloud.com/v1"), "/")
-	domain := os.Getenv("VERGE_DOMAIN")
-	token := os.Getenv("VERGE_TOKEN")
-	if base == "" || domain == "" || token == "" {
+func purgeVerge(ctx context.Context, provider config.ProviderConfig, target string) (interface{}, error) {
+	base, domain, headers, ok := purgeCredentials(provider)
+	if !ok {
 		return nil, errors.New("vergecloud token/domain missing on server")
 	}
-	endpoint := base + "/purge"
-	headers := map[string]string{
-		"X-API-Key":    token,
-		"Content-Type": "application/json",
-	}
-	body := map[string]interface{}{
-		"domain": domain,
-		"files":  []string{target},
+	call := vergePurge(domain, target)
+	return performJSONRequest(ctx, base+call.path, headers, call.body)
+}
+
+func vergePurge(domain, target string) purgeCall {
+	return purgeCall{
+		path: "/purge",
+		body: map[string]interface{}{
+			"domain": domain,
+			"files":  []string{target},
+		},
 	}
-	return performJSONRequest(endpoint, headers, body)
 }
 
-func performJSONRequest(url string, headers map[string]string, payload interface{}) (interface{}, error) {
+func performJSONRequest(ctx context.Context, url string, headers map[string]string, payload interface{}) (interface{}, error) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -97,8 +215,7 @@ func performJSONRequest(url string, headers map[string]string, payload interface
 		req.Header.Set(k, v)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := purgeClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -108,13 +225,17 @@ func performJSONRequest(url string, headers map[string]string, payload interface
 	if err != nil {
 		return nil, err
 	}
+	return newPurgeResult(resp.StatusCode, body), nil
+}
 
+// newPurgeResult decodes a JSON response body, keeping any other body as
+// text.
+func newPurgeResult(status int, body []byte) purgeResult {
 	var parsed interface{}
 	if err := json.Unmarshal(body, &parsed); err != nil {
 		parsed = string(body)
 	}
-
-	return purgeResult{Status: resp.StatusCode, Data: parsed}, nil
+	return purgeResult{Status: status, Data: parsed}
 }
 
 func envOr(key, fallback string) string {
@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a client-side limiter: it holds up to burst tokens,
+// refilled at rate per second, and every API attempt takes one. A single
+// bucket per provider is shared by every run using the registry.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it. Tokens may go negative, which queues callers in arrival order.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token, used when the caller gave up waiting.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// Wait blocks until a token is available or ctx is done, and returns the
+// time spent queued.
+func (b *tokenBucket) Wait(ctx context.Context) (time.Duration, error) {
+	wait := b.reserve()
+	if wait <= 0 {
+		return 0, nil
+	}
+	if err := sleepContext(ctx, wait); err != nil {
+		b.cancel()
+		return 0, err
+	}
+	return wait, nil
+}
+
+// limiterFor returns the provider's bucket, or nil when the provider has no
+// rate configured.
+func (r *Registry) limiterFor(providerID string) *tokenBucket {
+	r.limitersMu.Lock()
+	defer r.limitersMu.Unlock()
+
+	if b, ok := r.limiters[providerID]; ok {
+		return b
+	}
+	provider, ok := r.configs[providerID]
+	if !ok || provider.RateLimit <= 0 {
+		return nil
+	}
+	b := newTokenBucket(provider.RateLimit, provider.RateBurst)
+	r.limiters[providerID] = b
+	return b
+}
@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(2, 2)
+	b.last = now
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if wait := b.reserve(); wait != 0 {
+			t.Fatalf("burst token %d: expected no wait, got %v", i, wait)
+		}
+	}
+	if wait := b.reserve(); wait != 500*time.Millisecond {
+		t.Fatalf("expected 500ms wait once the burst is spent, got %v", wait)
+	}
+	if wait := b.reserve(); wait != time.Second {
+		t.Fatalf("expected queued caller to wait 1s, got %v", wait)
+	}
+
+	now = now.Add(5 * time.Second)
+	if wait := b.reserve(); wait != 0 {
+		t.Fatalf("expected refilled bucket, got wait %v", wait)
+	}
+}
+
+func TestTokenBucketWaitCancelled(t *testing.T) {
+	b := newTokenBucket(0.1, 1)
+	b.reserve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestSendReportsQueueWait(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, RateLimit: 20, RateBurst: 1},
+		},
+	})
+
+	first, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.QueueWait != 0 {
+		t.Fatalf("expected first call to skip the queue, waited %v", first.QueueWait)
+	}
+	if second.QueueWait < 20*time.Millisecond {
+		t.Fatalf("expected second call to queue behind the limiter, waited %v", second.QueueWait)
+	}
+}
+
+func TestPurgeSharesProviderRateLimiter(t *testing.T) {
+	var paths []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		_, _ = w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer api.Close()
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {
+				ID:         "arvan",
+				APIBase:    api.URL,
+				Domain:     "example.com",
+				AuthHeader: "Authorization",
+				AuthScheme: "apikey ",
+				Headers:    map[string]string{"Authorization": "apikey token"},
+				RateLimit:  20,
+				RateBurst:  1,
+			},
+		},
+	})
+	if _, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := reg.Purge(context.Background(), "arvancloud", "https://example.com/a.css")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	purge := result.(purgeResult)
+	if purge.Status != http.StatusOK || purge.QueueWait < 20 {
+		t.Fatalf("expected purge to queue behind the limiter, got status %d after %dms", purge.Status, purge.QueueWait)
+	}
+	if len(paths) != 2 || paths[1] != "POST /domains/example.com/caching/purge" {
+		t.Fatalf("unexpected calls %v", paths)
+	}
+}
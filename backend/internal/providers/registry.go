@@ -1,43 +1,215 @@
 package providers
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
 )
 
+// DefaultTimeout bounds a provider API call when neither the provider nor
+// the resource configures its own timeout.
+const DefaultTimeout = 30 * time.Second
+
 type Registry struct {
 	configs map[string]config.ProviderConfig
 	client  *http.Client
+	retry   RetryPolicy
+
+	limitersMu sync.Mutex
+	limiters   map[string]*tokenBucket
+
+	specsMu    sync.RWMutex
+	specs      map[string]*openapi.Document
+	operations map[string]map[string]openapi.OperationRef
+	coverage   coverageTracker
 }
 
+// Response is the outcome of a provider API call, including how many
+// attempts the retry policy needed and how long they spent queued behind
+// the provider's rate limiter.
+type Response struct {
+	Body      []byte
+	Status    int
+	Attempts  int
+	QueueWait time.Duration
+}
+
+// NewRegistry builds a registry whose client has no global timeout; every
+// call is bounded by its context and the provider/resource timeout instead.
 func NewRegistry(cfg config.Config) *Registry {
+	retry := DefaultRetryPolicy
+	if cfg.APIMaxAttempts > 0 {
+		retry.MaxAttempts = cfg.APIMaxAttempts
+	}
+	client := &http.Client{}
+	if cfg.APIFixtureMode == FixtureRecord || cfg.APIFixtureMode == FixtureReplay {
+		client.Transport = newFixtureTransport(cfg.APIFixtureMode, cfg.APIFixtureDir, cfg.Providers, http.DefaultTransport)
+	}
 	return &Registry{
-		configs: cfg.Providers,
-		client:  &http.Client{Timeout: 30 * time.Second},
+		configs:    cfg.Providers,
+		client:     client,
+		retry:      retry,
+		limiters:   make(map[string]*tokenBucket),
+		specs:      make(map[string]*openapi.Document),
+		operations: make(map[string]map[string]openapi.OperationRef),
 	}
 }
 
+// SetRetryPolicy replaces the retry policy used by subsequent calls.
+func (r *Registry) SetRetryPolicy(p RetryPolicy) {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	r.retry = p
+}
+
 func (r *Registry) Call(providerID, resource string) ([]byte, int, error) {
-	provider, ok := r.configs[providerID]
-	if !ok {
-		return nil, 0, fmt.Errorf("unknown provider: %s", providerID)
+	return r.CallContext(context.Background(), providerID, resource)
+}
+
+// CallContext is Call bound to ctx: cancelling ctx aborts the request.
+func (r *Registry) CallContext(ctx context.Context, providerID, resource string) ([]byte, int, error) {
+	return r.Do(ctx, providerID, http.MethodGet, resource, nil)
+}
+
+// Do issues method against the provider API resource. Resources resolve the
+// same way as for Call, so "dns" maps to the provider's DNS records path and
+// anything else is passed through as a raw path.
+func (r *Registry) Do(ctx context.Context, providerID, method, resource string, body []byte) ([]byte, int, error) {
+	resp, err := r.Send(ctx, providerID, method, resource, body)
+	return resp.Body, resp.Status, err
+}
+
+// Send is Do returning the full Response. Transient failures are retried
+// according to the registry's RetryPolicy within the call's timeout, and
+// every attempt first takes a token from the provider's rate limiter.
+func (r *Registry) Send(ctx context.Context, providerID, method, resource string, body []byte) (Response, error) {
+	return r.send(ctx, providerID, method, resource, r.Timeout(providerID, resource), body)
+}
+
+// SendWithHeaders is Send with headers overriding the provider's configured
+// ones; an empty value removes the header. It probes how the API treats
+// other credentials, so its calls are not counted toward API coverage.
+func (r *Registry) SendWithHeaders(ctx context.Context, providerID, method, resource string, headers map[string]string, body []byte) (Response, error) {
+	return r.sendWithRetry(ctx, r.retry, providerID, method, resource, r.Timeout(providerID, resource), headers, body)
+}
+
+func (r *Registry) send(ctx context.Context, providerID, method, resource string, timeout time.Duration, body []byte) (Response, error) {
+	resp, err := r.sendWithRetry(ctx, r.retry, providerID, method, resource, timeout, nil, body)
+	if resp.Attempts > 0 {
+		r.recordCoverage(ctx, providerID, method, resource, resp.Status, err)
+	}
+	return resp, err
+}
+
+// sendWithRetry sends one call, retrying it as policy allows within
+// timeout.
+func (r *Registry) sendWithRetry(ctx context.Context, policy RetryPolicy, providerID, method, resource string, timeout time.Duration, headers map[string]string, body []byte) (Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	limiter := r.limiterFor(providerID)
+	var out Response
+	for {
+		req, err := r.newRequest(ctx, providerID, method, resource, headers, body)
+		if err != nil {
+			return out, err
+		}
+		if limiter != nil {
+			wait, err := limiter.Wait(ctx)
+			out.QueueWait += wait
+			if err != nil {
+				return out, err
+			}
+		}
+		out.Attempts++
+		// Only the last attempt's outcome is reported; a transport error
+		// must not carry the status and body of an earlier 503.
+		out.Status, out.Body = 0, nil
+
+		var header http.Header
+		resp, err := r.client.Do(req)
+		if err == nil {
+			header = resp.Header
+			out.Status = resp.StatusCode
+			out.Body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		if !policy.shouldRetry(out.Attempts, req, out.Status, err) {
+			return out, err
+		}
+		wait, ok := policy.delay(out.Attempts, header)
+		if !ok {
+			return out, err
+		}
+		if sleepErr := sleepContext(ctx, wait); sleepErr != nil {
+			if err == nil {
+				err = sleepErr
+			}
+			return out, err
+		}
+	}
+}
+
+// Timeout returns the time budget of a call to resource: the resource's
+// own timeout if configured, else the provider's, else DefaultTimeout.
+func (r *Registry) Timeout(providerID, resource string) time.Duration {
+	provider := r.configs[providerID]
+	key := strings.Trim(strings.TrimSpace(resource), "/")
+	if d, ok := provider.ResourceTimeouts[key]; ok && d > 0 {
+		return d
+	}
+	if provider.Timeout > 0 {
+		return provider.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Resolve returns the absolute URL Do would call with ctx, without sending
+// anything.
+func (r *Registry) Resolve(ctx context.Context, providerID, method, resource string) (string, error) {
+	req, err := r.newRequest(ctx, providerID, method, resource, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return req.URL.String(), nil
+}
+
+func (r *Registry) newRequest(ctx context.Context, providerID, method, resource string, headers map[string]string, body []byte) (*http.Request, error) {
+	provider, err := r.Provider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if !allowedMethods[method] {
+		return nil, fmt.Errorf("unsupported method: %s", method)
 	}
 
 	endpoint, err := resolveEndpoint(provider, resource)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
 	}
 
 	url := provider.APIBase + ensureLeadingSlash(endpoint)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	for k, v := range provider.Headers {
@@ -45,15 +217,34 @@ func (r *Registry) Call(providerID, resource string) ([]byte, int, error) {
 			req.Header.Set(k, v)
 		}
 	}
-
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return nil, 0, err
+	for k, v := range headers {
+		if v == "" {
+			req.Header.Del(k)
+			continue
+		}
+		req.Header.Set(k, v)
 	}
-	defer resp.Body.Close()
+	return req, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	return body, resp.StatusCode, err
+var allowedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// IsDestructive reports whether method may change provider state and
+// therefore needs an explicit confirmation. Only reads are exempt: a POST
+// purges caches or creates records just as PUT and DELETE change them.
+func IsDestructive(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead:
+		return false
+	default:
+		return true
+	}
 }
 
 func resolveEndpoint(provider config.ProviderConfig, resource string) (string, error) {
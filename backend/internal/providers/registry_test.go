@@ -1,9 +1,13 @@
 package providers
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
 )
@@ -49,3 +53,110 @@ func TestResolveEndpointRequiresDomain(t *testing.T) {
 		t.Fatal("expected error when domain missing")
 	}
 }
+
+func TestRegistryDoSendsMethodAndBody(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/domains/example.com/dns-records" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"type":"A"}` {
+			t.Fatalf("unexpected body: %s", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+	}
+
+	_, status, err := NewRegistry(cfg).Do(context.Background(), "arvan", "post", "dns", []byte(`{"type":"A"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+}
+
+func TestRegistrySendWithHeadersOverridesProviderHeaders(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "" || r.Header.Get("Authorization") != "Bearer other" {
+			t.Fatalf("unexpected credentials: %v", r.Header)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("expected the untouched headers to be kept, got %v", r.Header)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", APIBase: api.URL, Headers: map[string]string{"X-API-Key": "secret", "Content-Type": "application/json"}},
+		},
+	}
+	headers := map[string]string{"X-API-Key": "", "Authorization": "Bearer other"}
+	resp, err := NewRegistry(cfg).SendWithHeaders(context.Background(), "verge", http.MethodGet, "domains", headers, nil)
+	if err != nil || resp.Status != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %v", resp.Status, err)
+	}
+}
+
+func TestRegistryDoRejectsUnsupportedMethod(t *testing.T) {
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{"arvan": {ID: "arvan", APIBase: "http://127.0.0.1:1"}},
+	}
+	if _, _, err := NewRegistry(cfg).Do(context.Background(), "arvan", "TRACE", "domains", nil); err == nil {
+		t.Fatal("expected error for unsupported method")
+	}
+}
+
+func TestRegistryCallContextHonoursResourceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer api.Close()
+	defer close(release)
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {
+				ID:               "verge",
+				APIBase:          api.URL,
+				Timeout:          time.Minute,
+				ResourceTimeouts: map[string]time.Duration{"domains": 50 * time.Millisecond},
+			},
+		},
+	}
+	reg := NewRegistry(cfg)
+
+	if got := reg.Timeout("verge", "/domains"); got != 50*time.Millisecond {
+		t.Fatalf("expected resource timeout, got %s", got)
+	}
+	if got := reg.Timeout("verge", "ssl"); got != time.Minute {
+		t.Fatalf("expected provider timeout, got %s", got)
+	}
+
+	start := time.Now()
+	_, _, err := reg.CallContext(context.Background(), "verge", "domains")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("call was not aborted by the resource timeout")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := reg.CallContext(ctx, "verge", "domains"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled context to abort the call, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed provider API call is tried
+// again.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter spreads each delay by up to this fraction in either direction.
+	Jitter float64
+	// MaxRetryAfter is the longest Retry-After the policy will wait for;
+	// beyond it the response is returned as-is.
+	MaxRetryAfter time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   3,
+	BaseDelay:     500 * time.Millisecond,
+	MaxDelay:      10 * time.Second,
+	Jitter:        0.2,
+	MaxRetryAfter: time.Minute,
+}
+
+// retryableStatus lists the transient statuses worth another attempt.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isIdempotent reports whether repeating req cannot apply a change twice.
+// POST and PATCH become safe when the caller supplies an Idempotency-Key.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+// shouldRetry returns whether another attempt is allowed after attempt
+// number attempt (1-based) ended with resp or err. A 429 is retried for any
+// method, since the provider rejected the request before processing it.
+func (p RetryPolicy) shouldRetry(attempt int, req *http.Request, status int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if err != nil {
+		if req.Context().Err() != nil || errors.Is(err, ErrNoFixture) {
+			return false
+		}
+		return isIdempotent(req)
+	}
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return retryableStatus(status) && isIdempotent(req)
+}
+
+// delay returns the wait before attempt+1: Retry-After when present,
+// otherwise exponential backoff with jitter. ok is false when Retry-After
+// asks for longer than MaxRetryAfter.
+func (p RetryPolicy) delay(attempt int, header http.Header) (time.Duration, bool) {
+	if wait, found := parseRetryAfter(header.Get("Retry-After"), time.Now()); found {
+		if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+			return 0, false
+		}
+		return wait, true
+	}
+
+	backoff := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 {
+		backoff = math.Min(backoff, float64(p.MaxDelay))
+	}
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(backoff), true
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay seconds and an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, MaxRetryAfter: time.Second}
+
+func newRetryRegistry(t *testing.T, handler http.HandlerFunc) *Registry {
+	t.Helper()
+	api := httptest.NewServer(handler)
+	t.Cleanup(api.Close)
+
+	reg := NewRegistry(config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+	})
+	reg.SetRetryPolicy(fastRetry)
+	return reg
+}
+
+func TestSendRetriesTransientFailures(t *testing.T) {
+	calls := 0
+	reg := newRetryRegistry(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+
+	resp, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != http.StatusOK || resp.Attempts != 3 {
+		t.Fatalf("expected success on third attempt, got status %d after %d attempts", resp.Status, resp.Attempts)
+	}
+}
+
+func TestSendDropsStaleResponseOnTransportError(t *testing.T) {
+	calls := 0
+	reg := newRetryRegistry(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"message":"busy"}`))
+			return
+		}
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	})
+
+	resp, err := reg.Send(context.Background(), "arvan", http.MethodGet, "domains", nil)
+	if err == nil || resp.Attempts != 3 {
+		t.Fatalf("expected a transport error after 3 attempts, got %d attempts: %v", resp.Attempts, err)
+	}
+	if resp.Status != 0 || resp.Body != nil {
+		t.Fatalf("expected no stale status or body, got %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestSendDoesNotRetryNonIdempotentServerError(t *testing.T) {
+	calls := 0
+	reg := newRetryRegistry(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	resp, _ := reg.Send(context.Background(), "arvan", http.MethodPost, "dns", []byte(`{}`))
+	if resp.Attempts != 1 || calls != 1 {
+		t.Fatalf("expected a single POST attempt, got %d", resp.Attempts)
+	}
+}
+
+func TestSendRetriesTooManyRequestsHonouringRetryAfter(t *testing.T) {
+	var first time.Time
+	reg := newRetryRegistry(t, func(w http.ResponseWriter, r *http.Request) {
+		if first.IsZero() {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if time.Since(first) < 900*time.Millisecond {
+			t.Errorf("retried before Retry-After elapsed")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	resp, err := reg.Send(context.Background(), "arvan", http.MethodPost, "dns", []byte(`{}`))
+	if err != nil || resp.Status != http.StatusCreated || resp.Attempts != 2 {
+		t.Fatalf("expected POST to be retried after 429, got %+v (%v)", resp, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if d, ok := parseRetryAfter("120", now); !ok || d != 2*time.Minute {
+		t.Fatalf("unexpected seconds parse: %s %v", d, ok)
+	}
+	if d, ok := parseRetryAfter("Mon, 01 Jan 2024 00:00:30 GMT", now); !ok || d != 30*time.Second {
+		t.Fatalf("unexpected date parse: %s %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Fatal("expected garbage to be ignored")
+	}
+}
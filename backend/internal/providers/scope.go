@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+// Scope selects one of a provider's named domains and credential profiles.
+// Empty fields keep the provider's defaults.
+type Scope struct {
+	Domain  string
+	Profile string
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context whose registry calls use s. The scope applies
+// to every provider called with the context.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	if s == (Scope{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// ScopeFrom returns the scope carried by ctx.
+func ScopeFrom(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Provider returns the configuration of providerID with the scope of ctx
+// applied.
+func (r *Registry) Provider(ctx context.Context, providerID string) (config.ProviderConfig, error) {
+	provider, ok := r.configs[providerID]
+	if !ok {
+		return provider, fmt.Errorf("unknown provider: %s", providerID)
+	}
+	s := ScopeFrom(ctx)
+	return provider.Scoped(s.Domain, s.Profile)
+}
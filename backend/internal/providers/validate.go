@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// ValidateResponse checks a response to method+resource, sent with ctx,
+// against the response schema of the matching operation in the provider's
+// spec. It returns nil when the provider has no spec loaded.
+func (r *Registry) ValidateResponse(ctx context.Context, providerID, method, resource string, status int, body []byte) *openapi.ValidationReport {
+	doc := r.Spec(providerID)
+	if doc == nil {
+		return nil
+	}
+	if op, ok := r.matchOperation(ctx, providerID, method, resource); ok {
+		return doc.ValidateResponse(op, status, body)
+	}
+	return &openapi.ValidationReport{
+		Status:  status,
+		Valid:   true,
+		Skipped: fmt.Sprintf("no %s operation in the spec matches %s", method, resource),
+	}
+}
+
+// ValidateOperation checks a response to operationID against its spec.
+func (r *Registry) ValidateOperation(providerID, operationID string, status int, body []byte) *openapi.ValidationReport {
+	doc := r.Spec(providerID)
+	op, ok := r.Operation(providerID, operationID)
+	if doc == nil || !ok {
+		return nil
+	}
+	return doc.ValidateResponse(op, status, body)
+}
+
+func basePath(apiBase string) string {
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(u.Path, "/")
+}
@@ -0,0 +1,115 @@
+// Package redact scrubs credentials from text before it leaves the
+// backend: API responses, SSE events, logs and recorded fixtures.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Placeholder replaces every redacted value.
+const Placeholder = "REDACTED"
+
+// SensitiveName matches header, query and JSON member names whose values
+// are credentials.
+var SensitiveName = regexp.MustCompile(`(?i)(auth|token|secret|password|passwd|api[-_]?key|apikey|cookie|credential|private[-_]?key|signature)`)
+
+// Secrets shorter than this are not scrubbed; replacing them would mangle
+// unrelated provider data. API tokens are far longer.
+const minSecretLen = 16
+
+var (
+	// jsonAuth matches the value of an "Authorization" or "X-API-Key" JSON
+	// member.
+	jsonAuth = regexp.MustCompile(`(?i)("(?:authorization|x-api-key)"\s*:\s*")(?:[^"\\]|\\.)*"`)
+	// textAuth matches the value of such a header in header dumps, error
+	// messages and query strings, keeping a leading scheme such as
+	// "apikey ".
+	textAuth = regexp.MustCompile(`(?i)(\b(?:authorization|x-api-key)\s*[:=]\s*(?:(?:bearer|apikey|basic|token)\s+)?)[^\s"'\\,;&]+`)
+)
+
+// Redactor replaces known secrets, and any Authorization or X-API-Key
+// value, with Placeholder. A nil Redactor only scrubs the header values.
+type Redactor struct {
+	secrets []string
+}
+
+// New returns a Redactor for secrets. Empty and short values are ignored.
+// Each secret is also matched in the forms encoding/json writes it, so a
+// token with quotes, backslashes or HTML characters is caught in encoded
+// responses.
+func New(secrets ...string) *Redactor {
+	seen := make(map[string]bool, len(secrets))
+	r := &Redactor{}
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minSecretLen {
+			continue
+		}
+		for _, form := range append([]string{secret}, jsonForms(secret)...) {
+			if !seen[form] {
+				seen[form] = true
+				r.secrets = append(r.secrets, form)
+			}
+		}
+	}
+	// Longest first, so a token containing another is replaced whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// jsonForms returns secret as it appears inside a JSON string, with and
+// without HTML escaping.
+func jsonForms(secret string) []string {
+	var out []string
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(secret); err != nil {
+			continue
+		}
+		encoded := strings.TrimSpace(buf.String())
+		out = append(out, encoded[1:len(encoded)-1])
+	}
+	return out
+}
+
+// String returns s with credentials replaced.
+func (r *Redactor) String(s string) string {
+	if r != nil {
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, Placeholder)
+		}
+	}
+	s = jsonAuth.ReplaceAllString(s, `${1}`+Placeholder+`"`)
+	return textAuth.ReplaceAllString(s, `${1}`+Placeholder)
+}
+
+// Bytes is String for byte slices. Valid JSON stays valid.
+func (r *Redactor) Bytes(b []byte) []byte {
+	return []byte(r.String(string(b)))
+}
+
+// Writer returns an io.Writer that redacts each write before passing it
+// to w. Secrets split across two writes are not caught, so it suits
+// writers that get whole messages, such as a log.Logger's output.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return writer{w: w, r: r}
+}
+
+type writer struct {
+	w io.Writer
+	r *Redactor
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.r.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,56 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedactorScrubsSecretsAndAuthValues(t *testing.T) {
+	r := New("s3cr3t-token-0123456789", "", "shortkey")
+
+	for _, tc := range []struct{ in, want string }{
+		{`{"note":"key s3cr3t-token-0123456789"}`, `{"note":"key REDACTED"}`},
+		{`{"headers":{"Authorization":"Bearer unknown-123","X-Api-Key":"k\"ey"}}`, `{"headers":{"Authorization":"REDACTED","X-Api-Key":"REDACTED"}}`},
+		{`{"error":"sent Authorization: apikey other-456\n"}`, `{"error":"sent Authorization: apikey REDACTED\n"}`},
+		{`GET /x?x-api-key=q1w2e3&page=2`, `GET /x?x-api-key=REDACTED&page=2`},
+		{`{"api_key_enabled":true,"shortkey":1}`, `{"api_key_enabled":true,"shortkey":1}`},
+	} {
+		got := r.String(tc.in)
+		if got != tc.want {
+			t.Errorf("String(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+		if json.Valid([]byte(tc.in)) && !json.Valid([]byte(got)) {
+			t.Errorf("redacting %s produced invalid JSON %s", tc.in, got)
+		}
+	}
+}
+
+func TestRedactorMatchesJSONEscapedSecrets(t *testing.T) {
+	secret := `tok&en<x>"q"\-0123456789`
+	r := New(secret)
+
+	escaped, _ := json.Marshal(map[string]string{"note": "echo " + secret})
+	var plain bytes.Buffer
+	enc := json.NewEncoder(&plain)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(map[string]string{"note": "echo " + secret})
+
+	for _, body := range [][]byte{escaped, plain.Bytes()} {
+		got := r.Bytes(body)
+		if bytes.Contains(got, []byte("0123456789")) || !json.Valid(got) {
+			t.Errorf("secret survived in %s: %s", body, got)
+		}
+	}
+}
+
+func TestRedactorWriterScrubsLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(New("s3cr3t-token-0123456789").Writer(&buf), "", 0)
+	logger.Printf("call failed with token s3cr3t-token-0123456789")
+	if strings.Contains(buf.String(), "s3cr3t-token-0123456789") || !strings.Contains(buf.String(), Placeholder) {
+		t.Fatalf("log line not redacted: %q", buf.String())
+	}
+}
@@ -2,26 +2,43 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/models"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/redact"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/tests"
 )
 
 type apiResponse struct {
-	Provider string      `json:"provider"`
-	Endpoint string      `json:"endpoint"`
-	Domain   string      `json:"domain,omitempty"`
-	Status   int         `json:"status"`
-	Success  bool        `json:"success"`
-	Data     interface{} `json:"data"`
-	Error    string      `json:"error,omitempty"`
+	Provider   string                    `json:"provider"`
+	Endpoint   string                    `json:"endpoint"`
+	Method     string                    `json:"method,omitempty"`
+	Domain     string                    `json:"domain,omitempty"`
+	Status     int                       `json:"status"`
+	Attempts   int                       `json:"attempts,omitempty"`
+	QueueWait  int64                     `json:"queueWait,omitempty"`
+	Total      int                       `json:"total,omitempty"`
+	Pages      int                       `json:"pages,omitempty"`
+	Truncated  bool                      `json:"truncated,omitempty"`
+	Normalized bool                      `json:"normalized,omitempty"`
+	Validation *openapi.ValidationReport `json:"validation,omitempty"`
+	Success    bool                      `json:"success"`
+	DryRun     bool                      `json:"dryRun,omitempty"`
+	URL        string                    `json:"url,omitempty"`
+	Data       interface{}               `json:"data"`
+	Error      string                    `json:"error,omitempty"`
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -31,47 +48,392 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPITest(w http.ResponseWriter, r *http.Request) {
 	providerID := s.normalizeProviderID(r.URL.Query().Get("provider"))
+	method := r.Method
 
 	resource := strings.TrimPrefix(r.URL.Path, "/api-test")
 	if resource == "" || resource == "/" {
 		writeJSON(w, http.StatusBadRequest, apiResponse{
 			Provider: providerID,
+			Method:   method,
 			Success:  false,
 			Error:    "missing resource",
 		})
 		return
 	}
 
-	body, status, err := s.registry.Call(providerID, resource)
+	ctx, err := s.scopedContext(r, providerID, r.URL.Query().Get("domain"), r.URL.Query().Get("profile"))
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, apiResponse{
 			Provider: providerID,
 			Endpoint: resource,
-			Status:   status,
+			Method:   method,
 			Success:  false,
 			Error:    err.Error(),
 		})
 		return
 	}
+	r = r.WithContext(ctx)
+
+	var payload []byte
+	if method != http.MethodGet {
+		var err error
+		payload, err = io.ReadAll(io.LimitReader(r.Body, maxAPIBody))
+		if err == nil && len(payload) > 0 && !json.Valid(payload) {
+			err = errors.New("request body must be JSON")
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiResponse{
+				Provider: providerID,
+				Endpoint: resource,
+				Method:   method,
+				Success:  false,
+				Error:    err.Error(),
+			})
+			return
+		}
+	}
+
+	if r.URL.Query().Get("dryRun") == "true" {
+		url, err := s.registry.Resolve(r.Context(), providerID, method, resource)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, apiResponse{
+				Provider: providerID,
+				Endpoint: resource,
+				Method:   method,
+				DryRun:   true,
+				Success:  false,
+				Error:    err.Error(),
+			})
+			return
+		}
+		var data interface{}
+		if len(payload) > 0 {
+			data = json.RawMessage(payload)
+		}
+		writeJSON(w, http.StatusOK, apiResponse{
+			Provider: providerID,
+			Endpoint: resource,
+			Method:   method,
+			DryRun:   true,
+			URL:      url,
+			Success:  true,
+			Data:     data,
+		})
+		return
+	}
+
+	if providers.IsDestructive(method) {
+		if err := s.confirmWrite(r); err != nil {
+			writeJSON(w, http.StatusForbidden, apiResponse{
+				Provider: providerID,
+				Endpoint: resource,
+				Method:   method,
+				Success:  false,
+				Error:    err.Error(),
+			})
+			return
+		}
+	}
+
+	if method == http.MethodGet && providers.IsListResource(resource) {
+		s.handleAPIList(w, r, providerID, resource)
+		return
+	}
+
+	resp, err := s.registry.Send(r.Context(), providerID, method, resource, payload)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiResponse{
+			Provider:  providerID,
+			Endpoint:  resource,
+			Method:    method,
+			Status:    resp.Status,
+			Attempts:  resp.Attempts,
+			QueueWait: resp.QueueWait.Milliseconds(),
+			Success:   false,
+			Error:     err.Error(),
+		})
+		return
+	}
+
+	validation := s.registry.ValidateResponse(r.Context(), providerID, method, resource, resp.Status, resp.Body)
+	success := resp.Status >= 200 && resp.Status < 300 && schemaValid(validation)
+	if success && method == http.MethodGet && wantNormalized(r) {
+		s.writeNormalized(w, apiResponse{
+			Provider:  providerID,
+			Endpoint:  resource,
+			Method:    method,
+			Status:    resp.Status,
+			Attempts:  resp.Attempts,
+			QueueWait: resp.QueueWait.Milliseconds(),
+		}, resp.Body)
+		return
+	}
 
 	var data interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		data = string(body)
+	if err := json.Unmarshal(resp.Body, &data); err != nil {
+		data = string(resp.Body)
 	}
 
 	writeJSON(w, http.StatusOK, apiResponse{
-		Provider: providerID,
-		Endpoint: resource,
-		Status:   status,
-		Success:  status >= 200 && status < 300,
-		Data:     data,
+		Provider:   providerID,
+		Endpoint:   resource,
+		Method:     method,
+		Status:     resp.Status,
+		Attempts:   resp.Attempts,
+		QueueWait:  resp.QueueWait.Milliseconds(),
+		Success:    success,
+		Validation: validation,
+		Data:       data,
 	})
 }
 
+// scopedContext returns the request context with the named domain and
+// credential profile selected, once providerID is known to define them.
+func (s *Server) scopedContext(r *http.Request, providerID, domain, profile string) (context.Context, error) {
+	ctx := providers.WithScope(r.Context(), providers.Scope{Domain: domain, Profile: profile})
+	if _, err := s.registry.Provider(ctx, providerID); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func schemaValid(report *openapi.ValidationReport) bool {
+	return report == nil || report.Valid
+}
+
+func wantNormalized(r *http.Request) bool {
+	return r.URL.Query().Get("normalized") == "true"
+}
+
+// writeNormalized maps body onto the common model for base.Endpoint and
+// writes base with it. Resources without a model are a client error; a
+// body the mapper can't read is reported as a bad gateway.
+func (s *Server) writeNormalized(w http.ResponseWriter, base apiResponse, body []byte) {
+	data, err := models.Normalize(base.Provider, base.Endpoint, body)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, models.ErrUnsupported) {
+			status = http.StatusBadRequest
+		}
+		base.Error = err.Error()
+		writeJSON(w, status, base)
+		return
+	}
+	base.Success = true
+	base.Normalized = true
+	base.Data = data
+	writeJSON(w, http.StatusOK, base)
+}
+
+type catalogEntry struct {
+	Provider   string                       `json:"provider"`
+	Title      string                       `json:"title,omitempty"`
+	Version    string                       `json:"version,omitempty"`
+	Operations []providers.CatalogOperation `json:"operations"`
+}
+
+// handleCatalog lists the GET operations of every provider spec, or of the
+// one named by ?provider=.
+func (s *Server) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	ids := s.cfg.ProviderIDs()
+	if r.URL.Query().Get("provider") != "" {
+		ids = []string{s.normalizeProviderID(r.URL.Query().Get("provider"))}
+	}
+
+	entries := make([]catalogEntry, 0, len(ids))
+	for _, id := range ids {
+		entry := catalogEntry{Provider: id, Operations: s.registry.Catalog(id)}
+		if doc := s.registry.Spec(id); doc != nil {
+			entry.Title = doc.Info.Title
+			entry.Version = doc.Info.Version
+		}
+		entries = append(entries, entry)
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleCoverage reports, per checklist category, how many spec operations
+// each provider has and how many were called since startup. ?details=true
+// adds the per-operation records.
+func (s *Server) handleCoverage(w http.ResponseWriter, r *http.Request) {
+	ids := s.cfg.ProviderIDs()
+	if r.URL.Query().Get("provider") != "" {
+		ids = []string{s.normalizeProviderID(r.URL.Query().Get("provider"))}
+	}
+	writeJSON(w, http.StatusOK, s.registry.Coverage(ids, r.URL.Query().Get("details") == "true"))
+}
+
+// handleParity compares the specs of two providers, by default the two
+// configured ones. ?providers=arvan,verge picks them explicitly and
+// ?format=markdown returns a Markdown document instead of JSON.
+func (s *Server) handleParity(w http.ResponseWriter, r *http.Request) {
+	ids := s.cfg.ProviderIDs()
+	if list := r.URL.Query().Get("providers"); list != "" {
+		ids = nil
+		for _, id := range strings.Split(list, ",") {
+			ids = append(ids, s.normalizeProviderID(strings.TrimSpace(id)))
+		}
+	}
+	if len(ids) != 2 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "parity compares exactly two providers"})
+		return
+	}
+
+	report, err := s.registry.Parity(ids[0], ids[1])
+	if errors.Is(err, providers.ErrNoParityResources) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write([]byte(report.Markdown()))
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleOperation calls a spec operation by operationId. Query parameters
+// other than provider fill the operation's path and query parameters.
+func (s *Server) handleOperation(w http.ResponseWriter, r *http.Request) {
+	providerID := s.normalizeProviderID(r.URL.Query().Get("provider"))
+	operationID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api-test/op"), "/")
+	base := apiResponse{Provider: providerID, Endpoint: operationID, Method: http.MethodGet}
+
+	if r.Method != http.MethodGet {
+		base.Error = "only GET operations can be called"
+		writeJSON(w, http.StatusMethodNotAllowed, base)
+		return
+	}
+
+	params := r.URL.Query()
+	params.Del("provider")
+	params.Del("profile")
+	// A configured domain name selects that domain; any other value fills
+	// {domain} as given.
+	scope := providers.Scope{Profile: r.URL.Query().Get("profile")}
+	if name := params.Get("domain"); name != "" {
+		if _, err := s.registry.Provider(providers.WithScope(r.Context(), providers.Scope{Domain: name}), providerID); err == nil {
+			scope.Domain = name
+			params.Del("domain")
+		}
+	}
+	ctx, err := s.scopedContext(r, providerID, scope.Domain, scope.Profile)
+	if err != nil {
+		base.Error = err.Error()
+		writeJSON(w, http.StatusBadRequest, base)
+		return
+	}
+
+	// Request errors (unknown operation, missing parameter) are the
+	// caller's; anything after that is the provider failing us.
+	if _, err := s.registry.OperationPath(ctx, providerID, operationID, params); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, providers.ErrUnknownOperation) {
+			status = http.StatusNotFound
+		}
+		base.Error = err.Error()
+		writeJSON(w, status, base)
+		return
+	}
+
+	resp, err := s.registry.CallOperation(ctx, providerID, operationID, params)
+	if err != nil {
+		base.Status = resp.Status
+		base.Attempts = resp.Attempts
+		base.Error = err.Error()
+		writeJSON(w, http.StatusBadGateway, base)
+		return
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(resp.Body, &data); err != nil {
+		data = string(resp.Body)
+	}
+	base.Status = resp.Status
+	base.Attempts = resp.Attempts
+	base.QueueWait = resp.QueueWait.Milliseconds()
+	base.Validation = s.registry.ValidateOperation(providerID, operationID, resp.Status, resp.Body)
+	base.Success = resp.Status >= 200 && resp.Status < 300 && schemaValid(base.Validation)
+	base.Data = data
+	writeJSON(w, http.StatusOK, base)
+}
+
+// handleAPIList walks every page of a list resource, up to ?limit= items.
+func (s *Server) handleAPIList(w http.ResponseWriter, r *http.Request, providerID, resource string) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	list, err := s.registry.CallAll(r.Context(), providerID, resource, limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiResponse{
+			Provider:  providerID,
+			Endpoint:  resource,
+			Method:    http.MethodGet,
+			Status:    list.Status,
+			Attempts:  list.Attempts,
+			QueueWait: list.QueueWait.Milliseconds(),
+			Pages:     list.Pages,
+			Success:   false,
+			Error:     err.Error(),
+		})
+		return
+	}
+
+	response := apiResponse{
+		Provider:  providerID,
+		Endpoint:  resource,
+		Method:    http.MethodGet,
+		Status:    list.Status,
+		Attempts:  list.Attempts,
+		QueueWait: list.QueueWait.Milliseconds(),
+		Total:     list.Total,
+		Pages:     list.Pages,
+		Truncated: list.Truncated,
+		Data:      map[string]interface{}{"data": list.Items},
+	}
+	response.Validation = s.registry.ValidateResponse(r.Context(), providerID, http.MethodGet, resource, list.Status, list.LastPage)
+	response.Success = list.Status >= 200 && list.Status < 300 && schemaValid(response.Validation)
+	if response.Success && wantNormalized(r) {
+		items, err := json.Marshal(list.Items)
+		if err != nil {
+			items = []byte("[]")
+		}
+		response.Success = false
+		response.Data = nil
+		s.writeNormalized(w, response, items)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+const maxAPIBody = 1 << 20
+
+// confirmWrite checks the confirmation token sent with a destructive call
+// in the X-Confirm-Token header. It is never read from the query string,
+// which ends up in access logs, browser history and Referer headers.
+func (s *Server) confirmWrite(r *http.Request) error {
+	expected := s.cfg.WriteConfirmToken
+	if expected == "" {
+		return errors.New("destructive API calls are disabled: API_WRITE_CONFIRM_TOKEN not set")
+	}
+	got := r.Header.Get("X-Confirm-Token")
+	if got == "" {
+		return errors.New("confirmation token required for " + r.Method)
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(expected)) != 1 {
+		return errors.New("invalid confirmation token")
+	}
+	return nil
+}
+
 type purgeRequest struct {
 	Provider string `json:"provider"`
 	Type     string `json:"type"`
 	URL      string `json:"url"`
+	Domain   string `json:"domain,omitempty"`
+	Profile  string `json:"profile,omitempty"`
 }
 
 func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +458,26 @@ func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := providers.ExecutePurge(provider, req.URL)
+	// A purge is a write, so picking other credentials than the default
+	// ones needs the same confirmation as /api-test writes.
+	if req.Profile != "" && req.Profile != config.DefaultProfile {
+		if err := s.confirmWrite(r); err != nil {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if req.Domain != "" || req.Profile != "" {
+		var err error
+		ctx, err = s.scopedContext(r, providers.CanonicalID(provider), req.Domain, req.Profile)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := s.registry.Purge(ctx, provider, req.URL)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
 			"ok":    false,
@@ -151,7 +532,34 @@ func (s *Server) handleRunTestsStream(w http.ResponseWriter, r *http.Request) {
 	req := tests.RunRequest{
 		Rounds:       parseIntQuery(r, "rounds", 1),
 		DelaySeconds: parseIntQuery(r, "delay", 0),
-		Providers:    parseProvidersQuery(r.URL.Query().Get("providers")),
+		Providers:    parseListQuery(r.URL.Query().Get("providers")),
+		Suites:       parseListQuery(r.URL.Query().Get("suites")),
+		Resolvers:    parseListQuery(r.URL.Query().Get("resolvers")),
+		DualStack:    r.URL.Query().Get("dualStack") == "true",
+		Domain:       r.URL.Query().Get("domain"),
+		Profile:      r.URL.Query().Get("profile"),
+	}
+	if r.URL.Query().Has("loadRps") {
+		req.Load = &tests.LoadProfile{
+			RPS:             parseIntQuery(r, "loadRps", 0),
+			DurationSeconds: parseIntQuery(r, "loadDuration", 0),
+			RampUpSeconds:   parseIntQuery(r, "loadRampUp", 0),
+			Endpoints:       parseListQuery(r.URL.Query().Get("loadEndpoints")),
+		}
+	}
+	if r.URL.Query().Get("fuzz") == "true" {
+		req.Fuzz = &tests.FuzzConfig{
+			Operations: parseListQuery(r.URL.Query().Get("fuzzOperations")),
+			MaxCases:   parseIntQuery(r, "fuzzMaxCases", 0),
+		}
+	}
+	if r.URL.Query().Has("burstRps") {
+		req.Burst = &tests.BurstConfig{
+			RPS:             parseIntQuery(r, "burstRps", 0),
+			Workers:         parseIntQuery(r, "burstWorkers", 0),
+			DurationSeconds: parseIntQuery(r, "burstDuration", 0),
+			RecoverySeconds: parseIntQuery(r, "burstRecovery", 0),
+		}
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
@@ -220,7 +628,7 @@ func (s *Server) normalizeProviderID(value string) string {
 	return s.cfg.DefaultProviderID()
 }
 
-func parseProvidersQuery(value string) []string {
+func parseListQuery(value string) []string {
 	if value == "" {
 		return nil
 	}
@@ -260,11 +668,39 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// redactResponses scrubs credentials from every response body, so tokens
+// echoed by provider APIs never reach results, SSE events or reports.
+func redactResponses(next http.Handler, redactor *redact.Redactor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(redactingWriter{ResponseWriter: w, redactor: redactor}, r)
+	})
+}
+
+// redactingWriter redacts each write on its own. Handlers write whole JSON
+// documents and SSE events, so a secret is never split across writes.
+type redactingWriter struct {
+	http.ResponseWriter
+	redactor *redact.Redactor
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.ResponseWriter.Write(w.redactor.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w redactingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Confirm-Token")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
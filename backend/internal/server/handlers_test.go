@@ -0,0 +1,288 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+)
+
+func newTestServer(t *testing.T, apiHits *int, confirmToken string) http.Handler {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*apiHits++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(api.Close)
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+		WriteConfirmToken: confirmToken,
+	}
+	return New(cfg, providers.NewRegistry(cfg)).Handler()
+}
+
+func TestAPITestDeleteRequiresConfirmation(t *testing.T) {
+	hits := 0
+	h := newTestServer(t, &hits, "s3cret")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api-test/dns?provider=arvan", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || hits != 0 {
+		t.Fatalf("expected 403 without reaching the API, got %d (hits=%d)", rec.Code, hits)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api-test/dns?provider=arvan", nil)
+	req.Header.Set("X-Confirm-Token", "s3cret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || hits != 1 {
+		t.Fatalf("expected confirmed delete to reach the API, got %d (hits=%d)", rec.Code, hits)
+	}
+}
+
+func TestAPITestDestructiveDisabledWithoutToken(t *testing.T) {
+	hits := 0
+	h := newTestServer(t, &hits, "")
+
+	req := httptest.NewRequest(http.MethodPatch, "/api-test/caching?provider=arvan&confirm=anything", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || hits != 0 {
+		t.Fatalf("expected destructive calls to be disabled, got %d (hits=%d)", rec.Code, hits)
+	}
+}
+
+func TestAPITestIgnoresConfirmQueryParameter(t *testing.T) {
+	hits := 0
+	h := newTestServer(t, &hits, "s3cret")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api-test/dns?provider=arvan&confirm=s3cret", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || hits != 0 {
+		t.Fatalf("expected the token to be accepted from the header only, got %d (hits=%d)", rec.Code, hits)
+	}
+}
+
+func TestAPITestPostRequiresConfirmation(t *testing.T) {
+	hits := 0
+	h := newTestServer(t, &hits, "s3cret")
+
+	req := httptest.NewRequest(http.MethodPost, "/api-test/dns?provider=arvan", strings.NewReader(`{"type":"A"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || hits != 0 {
+		t.Fatalf("expected 403 without reaching the API, got %d (hits=%d)", rec.Code, hits)
+	}
+}
+
+func TestAPITestDryRunDoesNotCallProvider(t *testing.T) {
+	hits := 0
+	h := newTestServer(t, &hits, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/api-test/dns?provider=arvan&dryRun=true", strings.NewReader(`{"type":"A"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || hits != 0 {
+		t.Fatalf("expected dry run without API call, got %d (hits=%d)", rec.Code, hits)
+	}
+
+	var resp apiResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !resp.DryRun || resp.Method != http.MethodPost || !strings.HasSuffix(resp.URL, "/domains/example.com/dns-records") {
+		t.Fatalf("unexpected dry-run response: %+v", resp)
+	}
+}
+
+func TestAPITestNormalized(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"ssl_status":true,"tls_version":"TLSv1.2","hsts_status":true}}`))
+	}))
+	defer api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+	}
+	h := New(cfg, providers.NewRegistry(cfg)).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-test/ssl?provider=arvan&normalized=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var body struct {
+		Normalized bool `json:"normalized"`
+		Data       struct {
+			Enabled       bool   `json:"enabled"`
+			MinTLSVersion string `json:"minTlsVersion"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !body.Normalized || !body.Data.Enabled || body.Data.MinTLSVersion != "TLSv1.2" {
+		t.Fatalf("expected normalised SSL settings, got %s", rec.Body)
+	}
+}
+
+func TestDomainAndProfileSelectScope(t *testing.T) {
+	var paths, auths []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {
+				ID:         "arvan",
+				APIBase:    api.URL,
+				Domain:     "example.com",
+				AuthHeader: "Authorization",
+				AuthScheme: "apikey ",
+				Headers:    map[string]string{"Authorization": "apikey main-token"},
+				Domains:    []config.DomainConfig{{Name: "shop", Domain: "shop.example.net"}},
+				Profiles:   map[string]string{"readonly": "ro-token"},
+			},
+		},
+		WriteConfirmToken: "s3cret",
+	}
+	h := New(cfg, providers.NewRegistry(cfg)).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-test/ssl?provider=arvan&domain=shop&profile=readonly", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	purge := `{"provider":"arvan","url":"https://shop.example.net/a.css","domain":"shop","profile":"readonly"}`
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/purge", strings.NewReader(purge)))
+	if rec.Code != http.StatusForbidden || len(paths) != 1 {
+		t.Fatalf("expected a purge with another profile to need confirmation, got %d (calls=%d)", rec.Code, len(paths))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/purge", strings.NewReader(purge))
+	req.Header.Set("X-Confirm-Token", "s3cret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected scoped purge to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 API calls, got %v", paths)
+	}
+	for i, path := range paths {
+		if !strings.HasPrefix(path, "/domains/shop.example.net/") || auths[i] != "apikey ro-token" {
+			t.Errorf("call %d: expected the shop domain and readonly token, got %s with %q", i, path, auths[i])
+		}
+	}
+
+	for _, query := range []string{"domain=unknown", "profile=unknown"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-test/ssl?provider=arvan&"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+	if len(paths) != 2 {
+		t.Fatalf("unknown names must not reach the API, got %v", paths)
+	}
+}
+
+func TestResponsesRedactCredentials(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"echo":"` + r.Header.Get("Authorization") + `","headers":{"X-API-Key":"unknown-key"}}}`))
+	}))
+	defer api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {
+				ID:         "arvan",
+				APIBase:    api.URL,
+				Domain:     "example.com",
+				AuthHeader: "Authorization",
+				AuthScheme: "apikey ",
+				Headers:    map[string]string{"Authorization": "apikey s3cr3t-token-0123456789"},
+			},
+		},
+	}
+	h := New(cfg, providers.NewRegistry(cfg)).Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api-test/ssl?provider=arvan", nil))
+	body := rec.Body.String()
+	if strings.Contains(body, "s3cr3t-token-0123456789") || strings.Contains(body, "unknown-key") {
+		t.Fatalf("response leaks credentials: %s", body)
+	}
+	if !json.Valid(rec.Body.Bytes()) || !strings.Contains(body, "REDACTED") {
+		t.Fatalf("expected valid JSON with redacted values, got %s", body)
+	}
+}
+
+func TestOperationErrorStatuses(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	api.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", APIBase: api.URL, Domain: "example.com"},
+		},
+	}
+	registry := providers.NewRegistry(cfg)
+	registry.SetRetryPolicy(providers.RetryPolicy{MaxAttempts: 1})
+	doc, err := openapi.Parse([]byte(`{"openapi": "3.0.0", "paths": {
+	  "/domains/{domain}/records": {"get": {"operationId": "records.index"}},
+	  "/records/{id}": {"get": {"operationId": "records.show"}}
+	}}`), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.SetSpec("arvan", doc)
+	h := New(cfg, registry).Handler()
+
+	for path, want := range map[string]int{
+		"/api-test/op/records.index?provider=arvan": http.StatusBadGateway,
+		"/api-test/op/records.show?provider=arvan":  http.StatusBadRequest,
+		"/api-test/op/nope?provider=arvan":          http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d: %s", path, want, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestParityRejectsUnmappedProviders(t *testing.T) {
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan"},
+			"edge":  {ID: "edge"},
+		},
+	}
+	h := New(cfg, providers.NewRegistry(cfg)).Handler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/parity?providers=arvan,edge", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "edge") {
+		t.Fatalf("expected 400 for a provider without parity resources, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/redact"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/tests"
 )
 
@@ -12,6 +13,7 @@ type Server struct {
 	cfg      config.Config
 	registry *providers.Registry
 	runner   *tests.Runner
+	redactor *redact.Redactor
 }
 
 func New(cfg config.Config, registry *providers.Registry) *Server {
@@ -19,6 +21,7 @@ func New(cfg config.Config, registry *providers.Registry) *Server {
 		cfg:      cfg,
 		registry: registry,
 		runner:   tests.NewRunner(cfg, registry),
+		redactor: redact.New(cfg.Secrets()...),
 	}
 }
 
@@ -27,8 +30,12 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/api-test/", s.handleAPITest)
 	mux.HandleFunc("/api-test", s.handleAPITest)
+	mux.HandleFunc("/api-test/catalog", s.handleCatalog)
+	mux.HandleFunc("/api-test/op/", s.handleOperation)
+	mux.HandleFunc("/coverage", s.handleCoverage)
+	mux.HandleFunc("/parity", s.handleParity)
 	mux.HandleFunc("/purge", s.handlePurge)
 	mux.HandleFunc("/tests/run", s.handleRunTests)
 	mux.HandleFunc("/tests/run/stream", s.handleRunTestsStream)
-	return withCORS(loggingMiddleware(mux))
+	return withCORS(loggingMiddleware(redactResponses(mux, s.redactor)))
 }
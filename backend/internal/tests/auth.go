@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+const (
+	authMalformedToken = "%%not-a-token%%"
+	// authRevokedPlaceholder stands in for a revoked credential when the
+	// provider has no RevokedToken configured: well-formed, never issued.
+	authRevokedPlaceholder = "00000000000000000000000000000000"
+)
+
+// AuthReport records how a provider API answered one resource under each
+// kind of bad credential.
+type AuthReport struct {
+	Resource string        `json:"resource"`
+	Probes   []AuthAttempt `json:"probes"`
+}
+
+// AuthAttempt is a single call made with bad credentials. Leak explains
+// what the response exposed, if anything.
+type AuthAttempt struct {
+	Case   string `json:"case"`
+	Status int    `json:"status"`
+	Leak   string `json:"leak,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type authCase struct {
+	name    string
+	headers func(provider config.ProviderConfig, token string) map[string]string
+}
+
+// authCases are the credential variants sent to every resource. Overrides
+// apply on top of the provider's configured headers; an empty value drops
+// the header.
+var authCases = []authCase{
+	{name: "no credentials", headers: func(p config.ProviderConfig, _ string) map[string]string {
+		return map[string]string{p.AuthHeader: ""}
+	}},
+	{name: "malformed token", headers: func(p config.ProviderConfig, _ string) map[string]string {
+		return map[string]string{p.AuthHeader: p.AuthScheme + authMalformedToken}
+	}},
+	{name: "revoked token", headers: func(p config.ProviderConfig, _ string) map[string]string {
+		token := p.RevokedToken
+		if token == "" {
+			token = authRevokedPlaceholder
+		}
+		return map[string]string{p.AuthHeader: p.AuthScheme + token}
+	}},
+	{name: "wrong scheme", headers: func(p config.ProviderConfig, token string) map[string]string {
+		// A scheme-prefixed credential gets another scheme; a bare key header
+		// is moved to a bearer Authorization header instead.
+		if p.AuthScheme != "" {
+			return map[string]string{p.AuthHeader: "Bearer " + token}
+		}
+		return map[string]string{p.AuthHeader: "", "Authorization": "Bearer " + token}
+	}},
+}
+
+func authSuiteCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	var cases []testCase
+	for _, endpoint := range apiEndpoints {
+		resource := strings.TrimPrefix(endpoint.Path, "/api-test")
+		for _, providerID := range providerIDs {
+			// Resources the provider's API lacks have nothing to protect.
+			if _, err := r.registry.Resolve(context.Background(), providerID, http.MethodGet, resource); err != nil {
+				continue
+			}
+			resource, providerID := resource, providerID
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runAuthProbe(ctx, providerID, resource)
+			})
+		}
+	}
+	return cases
+}
+
+// runAuthProbe calls resource once per authCase and expects every call to
+// be rejected with 401 or 403 without returning data.
+func (r *Runner) runAuthProbe(ctx context.Context, providerID, resource string) Result {
+	id := "auth-" + strings.Trim(resource, "/")
+	name := "API Auth: " + strings.Trim(resource, "/")
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.AuthHeader == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider auth header not configured"))
+	}
+	url, err := r.registry.Resolve(ctx, providerID, http.MethodGet, resource)
+	if err != nil {
+		return errorResult(id, name, providerID, "", err)
+	}
+	token := strings.TrimPrefix(provider.Headers[provider.AuthHeader], provider.AuthScheme)
+
+	report := AuthReport{Resource: strings.Trim(resource, "/")}
+	var (
+		checks []Check
+		status int
+	)
+	start := time.Now()
+	for _, c := range authCases {
+		resp, err := r.registry.SendWithHeaders(ctx, providerID, http.MethodGet, resource, c.headers(provider, token), nil)
+		attempt := AuthAttempt{Case: c.name, Status: resp.Status}
+		if err != nil {
+			attempt.Error = err.Error()
+		} else {
+			attempt.Leak = authLeak(resp.Body, token)
+			status = resp.Status
+		}
+		report.Probes = append(report.Probes, attempt)
+
+		rejected := resp.Status == http.StatusUnauthorized || resp.Status == http.StatusForbidden
+		actual, leak := strconv.Itoa(resp.Status), attempt.Leak
+		if err != nil {
+			actual = err.Error()
+		} else if leak == "" {
+			leak = "no data"
+		}
+		checks = append(checks, Check{
+			Name:     c.name + " rejected",
+			Expected: "401/403",
+			Actual:   actual,
+			Passed:   err == nil && rejected,
+		}, Check{
+			Name:     c.name + " leaks nothing",
+			Expected: "no data",
+			Actual:   leak,
+			Passed:   err == nil && attempt.Leak == "",
+		})
+	}
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          url,
+		Status:       status,
+		Duration:     time.Since(start).Milliseconds(),
+		Success:      checksPassed(checks),
+		Checks:       checks,
+		Auth:         &report,
+	}
+}
+
+// authLeak reports what a rejected response exposed: resource data or the
+// credential itself echoed back.
+func authLeak(body []byte, token string) string {
+	if len(token) >= 4 && strings.Contains(string(body), token) {
+		return "credential echoed in body"
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	switch data := payload["data"].(type) {
+	case []interface{}:
+		if len(data) > 0 {
+			return strconv.Itoa(len(data)) + " data items returned"
+		}
+	case map[string]interface{}:
+		if len(data) > 0 {
+			return "data object returned"
+		}
+	case nil:
+	default:
+		return "data value returned"
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers/mock"
+)
+
+var authProvider = config.ProviderConfig{
+	ID:         "arvan",
+	Domain:     "example.com",
+	AuthHeader: "Authorization",
+	AuthScheme: "apikey ",
+	Headers:    map[string]string{"Authorization": "apikey secret-token"},
+}
+
+func authRunner(apiBase string) *Runner {
+	provider := authProvider
+	provider.APIBase = apiBase
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{"arvan": provider},
+	}
+	return NewRunner(cfg, providers.NewRegistry(cfg))
+}
+
+func TestAuthSuiteRejectsBadCredentials(t *testing.T) {
+	doc, err := openapi.Load(filepath.Join("..", "..", "..", "api", "arvancloud-api.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(mock.New(doc, mock.ProviderOptions(authProvider)))
+	defer api.Close()
+
+	res := authRunner(api.URL).runAuthProbe(context.Background(), "arvan", "/domains")
+	if !res.Success || len(res.Auth.Probes) != len(authCases) {
+		t.Fatalf("expected every bad credential to be rejected, got %+v", res.Checks)
+	}
+	for _, probe := range res.Auth.Probes {
+		if probe.Status != http.StatusUnauthorized {
+			t.Fatalf("%s: expected 401, got %d", probe.Case, probe.Status)
+		}
+	}
+}
+
+func TestAuthSuiteFlagsLeakingAPI(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Unauthenticated.","data":[{"domain":"example.com"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"domain":"example.com"}]}`))
+	}))
+	defer api.Close()
+
+	res := authRunner(api.URL).runAuthProbe(context.Background(), "arvan", "/domains")
+	if res.Success {
+		t.Fatalf("expected the suite to fail, got %+v", res.Checks)
+	}
+	missing := res.Auth.Probes[0]
+	if missing.Status != http.StatusUnauthorized || missing.Leak == "" {
+		t.Fatalf("expected a 401 that leaks data, got %+v", missing)
+	}
+	if wrong := res.Auth.Probes[3]; wrong.Status != http.StatusOK {
+		t.Fatalf("expected the wrong scheme to be accepted, got %+v", wrong)
+	}
+}
+
+func TestAuthLeakDetectsEchoedCredential(t *testing.T) {
+	if got := authLeak([]byte(`invalid key secret-token`), "secret-token"); got == "" {
+		t.Fatal("expected the echoed credential to be reported")
+	}
+	if got := authLeak([]byte(`{"message":"Unauthenticated.","data":[]}`), "secret-token"); got != "" {
+		t.Fatalf("expected no leak, got %q", got)
+	}
+}
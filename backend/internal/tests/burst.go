@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const burstPath = "/security/rate-test"
+
+// Hard caps applied to every burst, whatever the caller asks for, so a run
+// can never turn into a denial of service against our own origin.
+// maxBurstRequests is shared by all bursts of a run: every provider and
+// round.
+const (
+	maxBurstRPS      = 50
+	maxBurstWorkers  = 10
+	maxBurstDuration = 30 * time.Second
+	maxBurstRecovery = 60 * time.Second
+	maxBurstRequests = 1000
+)
+
+// burstRecoveryPoll is the interval between single probes while waiting for
+// a rate-limited edge to accept requests again.
+var burstRecoveryPoll = time.Second
+
+// BurstConfig controls the ratelimit suite. Zero values fall back to
+// defaults and every value is clamped to the hard caps above.
+type BurstConfig struct {
+	RPS             int `json:"rps"`
+	Workers         int `json:"workers"`
+	DurationSeconds int `json:"duration"`
+	RecoverySeconds int `json:"recoveryTimeout"`
+}
+
+// BurstReport describes when a provider started limiting the burst and how
+// long it took to recover.
+type BurstReport struct {
+	RPS               int               `json:"rps"`
+	Workers           int               `json:"workers"`
+	Sent              int               `json:"sent"`
+	Succeeded         int               `json:"succeeded"`
+	Blocked           int               `json:"blocked"`
+	Errors            int               `json:"errors"`
+	Skipped           int               `json:"skipped"`
+	FirstBlockAfterMs int64             `json:"firstBlockAfterMs,omitempty"`
+	FirstBlockRequest int               `json:"firstBlockRequest,omitempty"`
+	FirstBlockStatus  int               `json:"firstBlockStatus,omitempty"`
+	Recovered         bool              `json:"recovered"`
+	RecoveryMs        int64             `json:"recoveryMs,omitempty"`
+	RateLimitHeaders  map[string]string `json:"rateLimitHeaders,omitempty"`
+	// BudgetExhausted is set when the burst stopped because the run's
+	// request budget ran out.
+	BudgetExhausted bool `json:"budgetExhausted,omitempty"`
+}
+
+// burstBudget counts the burst requests a run may still send.
+type burstBudget struct {
+	mu   sync.Mutex
+	left int
+}
+
+func newBurstBudget() *burstBudget {
+	return &burstBudget{left: maxBurstRequests}
+}
+
+func (b *burstBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.left <= 0 {
+		return false
+	}
+	b.left--
+	return true
+}
+
+func (b *burstBudget) spent() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.left <= 0
+}
+
+func (b *burstBudget) refund() {
+	b.mu.Lock()
+	b.left++
+	b.mu.Unlock()
+}
+
+func (c BurstConfig) normalized() BurstConfig {
+	out := c
+	if out.RPS <= 0 {
+		out.RPS = 10
+	}
+	if out.Workers <= 0 {
+		out.Workers = 4
+	}
+	if out.DurationSeconds <= 0 {
+		out.DurationSeconds = 10
+	}
+	if out.RecoverySeconds <= 0 {
+		out.RecoverySeconds = 30
+	}
+	out.RPS = min(out.RPS, maxBurstRPS)
+	out.Workers = min(out.Workers, maxBurstWorkers)
+	out.DurationSeconds = min(out.DurationSeconds, int(maxBurstDuration/time.Second))
+	out.RecoverySeconds = min(out.RecoverySeconds, int(maxBurstRecovery/time.Second))
+	if out.RPS*out.DurationSeconds > maxBurstRequests {
+		out.DurationSeconds = maxBurstRequests / out.RPS
+	}
+	return out
+}
+
+func burstCases(r *Runner, req RunRequest, providerIDs []string) []testCase {
+	cfg := BurstConfig{}
+	if req.Burst != nil {
+		cfg = *req.Burst
+	}
+	cfg = cfg.normalized()
+
+	budget := newBurstBudget()
+	cases := make([]testCase, 0, len(providerIDs))
+	for _, providerID := range providerIDs {
+		providerID := providerID
+		cases = append(cases, func(ctx context.Context) Result {
+			return r.runBurstTest(ctx, cfg, budget, providerID)
+		})
+	}
+	return cases
+}
+
+func isRateLimited(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusForbidden
+}
+
+// runBurstTest fires requests at a fixed rate from a bounded worker pool.
+// Ticks that find every worker busy are skipped rather than queued, so the
+// offered rate never exceeds cfg.RPS. The burst stops at the first 429/403
+// and the edge is then polled until it serves a 2xx again. Every request
+// is taken from the run's budget; the burst ends when it runs out.
+func (r *Runner) runBurstTest(ctx context.Context, cfg BurstConfig, budget *burstBudget, providerID string) Result {
+	const id, name = "ratelimit-burst", "Rate-Limit Burst"
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+	target := provider.OriginURL + burstPath
+	if budget.spent() {
+		return errorResult(id, name, providerID, target, errors.New("burst request budget of this run is spent"))
+	}
+
+	burstCtx, stop := context.WithTimeout(ctx, time.Duration(cfg.DurationSeconds)*time.Second)
+	defer stop()
+
+	report := BurstReport{RPS: cfg.RPS, Workers: cfg.Workers}
+	var (
+		mu      sync.Mutex
+		blocked time.Time
+		wg      sync.WaitGroup
+	)
+	start := time.Now()
+	jobs := make(chan int)
+
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range jobs {
+				resp, err := r.probe(burstCtx, http.MethodGet, target, nil)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					if burstCtx.Err() == nil {
+						report.Errors++
+					}
+				case isRateLimited(resp.Status):
+					report.Blocked++
+					if blocked.IsZero() {
+						blocked = time.Now()
+						report.FirstBlockAfterMs = blocked.Sub(start).Milliseconds()
+						report.FirstBlockRequest = seq
+						report.FirstBlockStatus = resp.Status
+						report.RateLimitHeaders = rateLimitHeaders(resp.Header)
+						stop()
+					}
+				default:
+					report.Succeeded++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(cfg.RPS))
+	seq := 0
+dispatch:
+	for {
+		select {
+		case <-burstCtx.Done():
+			break dispatch
+		case <-ticker.C:
+			if !budget.take() {
+				mu.Lock()
+				report.BudgetExhausted = true
+				mu.Unlock()
+				break dispatch
+			}
+			seq++
+			select {
+			case jobs <- seq:
+				mu.Lock()
+				report.Sent++
+				mu.Unlock()
+			default:
+				budget.refund()
+				mu.Lock()
+				report.Skipped++
+				mu.Unlock()
+			}
+		}
+	}
+	ticker.Stop()
+	close(jobs)
+	wg.Wait()
+
+	if !blocked.IsZero() {
+		report.Recovered, report.RecoveryMs = r.awaitRecovery(ctx, target, blocked, time.Duration(cfg.RecoverySeconds)*time.Second)
+	}
+
+	if ctx.Err() != nil {
+		return errorResult(id, name, providerID, target, ctx.Err())
+	}
+
+	checks := []Check{{
+		Name:     "rate limit enforced",
+		Expected: "429 or 403 during burst",
+		Actual:   burstOutcome(report),
+		Passed:   report.FirstBlockStatus != 0,
+	}}
+	if report.FirstBlockStatus != 0 {
+		checks = append(checks, Check{
+			Name:     "recovered",
+			Expected: "2xx within recovery timeout",
+			Actual:   burstRecovery(report),
+			Passed:   report.Recovered,
+		})
+	}
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          target,
+		Status:       report.FirstBlockStatus,
+		Duration:     time.Since(start).Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      report.RateLimitHeaders,
+		Checks:       checks,
+		Burst:        &report,
+	}
+}
+
+func (r *Runner) awaitRecovery(ctx context.Context, target string, blocked time.Time, timeout time.Duration) (bool, int64) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, 0
+		case <-deadline.C:
+			return false, 0
+		case <-time.After(burstRecoveryPoll):
+		}
+
+		resp, err := r.probe(ctx, http.MethodGet, target, nil)
+		if err == nil && resp.Status >= 200 && resp.Status < 300 {
+			return true, time.Since(blocked).Milliseconds()
+		}
+	}
+}
+
+// rateLimitHeaders keeps Retry-After and the common X-RateLimit-* and
+// IETF RateLimit-* headers from a limited response.
+func rateLimitHeaders(h http.Header) map[string]string {
+	out := make(map[string]string)
+	for key, values := range h {
+		lower := strings.ToLower(key)
+		if lower == "retry-after" || strings.HasPrefix(lower, "x-ratelimit") || strings.HasPrefix(lower, "ratelimit") {
+			out[key] = strings.Join(values, ", ")
+		}
+	}
+	return out
+}
+
+func burstOutcome(report BurstReport) string {
+	if report.FirstBlockStatus == 0 {
+		return "no limit after " + strconv.Itoa(report.Sent) + " requests"
+	}
+	return strconv.Itoa(report.FirstBlockStatus) + " at request " + strconv.Itoa(report.FirstBlockRequest)
+}
+
+func burstRecovery(report BurstReport) string {
+	if !report.Recovered {
+		return "not recovered"
+	}
+	return strconv.Itoa(int(report.RecoveryMs)) + "ms"
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestBurstConfigClampsToHardCaps(t *testing.T) {
+	cfg := BurstConfig{RPS: 10000, Workers: 500, DurationSeconds: 3600, RecoverySeconds: 3600}.normalized()
+	if cfg.RPS != maxBurstRPS || cfg.Workers != maxBurstWorkers {
+		t.Fatalf("expected rps/workers clamped, got %+v", cfg)
+	}
+	if cfg.RPS*cfg.DurationSeconds > maxBurstRequests {
+		t.Fatalf("expected total requests capped at %d, got %+v", maxBurstRequests, cfg)
+	}
+	if cfg.RecoverySeconds != int(maxBurstRecovery/time.Second) {
+		t.Fatalf("expected recovery clamped, got %d", cfg.RecoverySeconds)
+	}
+}
+
+func TestBurstRecordsFirstBlockAndRecovery(t *testing.T) {
+	oldPoll := burstRecoveryPoll
+	burstRecoveryPoll = 20 * time.Millisecond
+	defer func() { burstRecoveryPoll = oldPoll }()
+
+	var (
+		mu        sync.Mutex
+		hits      int
+		blockedAt time.Time
+	)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits++
+		if hits > 5 {
+			if blockedAt.IsZero() {
+				blockedAt = time.Now()
+			}
+			if time.Since(blockedAt) < 100*time.Millisecond {
+				w.Header().Set("Retry-After", "1")
+				w.Header().Set("X-RateLimit-Limit", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: origin.URL},
+		},
+	}
+	runner := NewRunner(cfg, nil)
+
+	res := runner.runBurstTest(context.Background(), BurstConfig{RPS: 50, Workers: 2, DurationSeconds: 2, RecoverySeconds: 2}.normalized(), newBurstBudget(), "verge")
+	if res.Burst == nil {
+		t.Fatal("expected burst report")
+	}
+	if res.Burst.FirstBlockStatus != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 to be recorded, got %+v", res.Burst)
+	}
+	if res.Burst.RateLimitHeaders["Retry-After"] != "1" || res.Burst.RateLimitHeaders["X-Ratelimit-Limit"] != "5" {
+		t.Fatalf("expected rate-limit headers, got %+v", res.Burst.RateLimitHeaders)
+	}
+	if !res.Burst.Recovered || !res.Success {
+		t.Fatalf("expected recovery, got %+v checks %+v", res.Burst, res.Checks)
+	}
+}
+
+func TestBurstBudgetIsSharedAcrossProviders(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits int
+	)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: origin.URL},
+			"arvan": {ID: "arvan", OriginURL: origin.URL},
+		},
+	}
+	runner := NewRunner(cfg, nil)
+	budget := &burstBudget{left: 5}
+	burst := BurstConfig{RPS: 50, Workers: 2, DurationSeconds: 1}.normalized()
+
+	first := runner.runBurstTest(context.Background(), burst, budget, "verge")
+	if first.Burst == nil || !first.Burst.BudgetExhausted || first.Burst.Sent > 5 {
+		t.Fatalf("expected the first burst to stop at the budget, got %+v", first.Burst)
+	}
+	second := runner.runBurstTest(context.Background(), burst, budget, "arvan")
+	if second.Error == "" {
+		t.Fatalf("expected the second burst to find the budget spent, got %+v", second)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if hits > 5 {
+		t.Fatalf("expected at most 5 requests in the run, got %d", hits)
+	}
+}
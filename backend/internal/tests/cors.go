@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type corsProbe struct {
+	ID             string
+	Name           string
+	Method         string
+	Path           string
+	Origin         string
+	RequestMethod  string
+	RequestHeaders string
+	AllowMethods   []string
+	MaxAge         string
+	ExposeHeaders  []string
+}
+
+// corsProbes mirror the Access-Control-* headers nginx sets on the probe
+// locations; each one must survive the provider edge unchanged.
+var corsProbes = []corsProbe{
+	{
+		ID:             "cors-preflight-get",
+		Name:           "CORS Preflight (GET)",
+		Method:         http.MethodOptions,
+		Path:           "/probe.txt",
+		Origin:         "https://dashboard.example.com",
+		RequestMethod:  http.MethodGet,
+		RequestHeaders: "Content-Type",
+		AllowMethods:   []string{"GET", "HEAD", "OPTIONS"},
+		MaxAge:         "86400",
+	},
+	{
+		ID:             "cors-preflight-head",
+		Name:           "CORS Preflight (HEAD, custom header)",
+		Method:         http.MethodOptions,
+		Path:           "/large-probe.txt",
+		Origin:         "http://localhost:5173",
+		RequestMethod:  http.MethodHead,
+		RequestHeaders: "X-Requested-With, Accept",
+		AllowMethods:   []string{"HEAD"},
+		MaxAge:         "86400",
+	},
+	{
+		ID:     "cors-get-static",
+		Name:   "CORS GET (static probe)",
+		Method: http.MethodGet,
+		Path:   "/probe.txt",
+		Origin: "https://dashboard.example.com",
+		ExposeHeaders: []string{
+			"Cache-Control", "Age", "ETag", "Last-Modified", "X-Cache-Status",
+			"X-Served-By", "Timing-Allow-Origin",
+		},
+	},
+	{
+		ID:            "cors-get-time",
+		Name:          "CORS GET (/api/time)",
+		Method:        http.MethodGet,
+		Path:          "/api/time",
+		Origin:        "null",
+		ExposeHeaders: []string{"Cache-Control", "X-Cache-TTL", "X-Geo-Country", "X-Visitor-IP"},
+	},
+}
+
+func corsCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	cases := make([]testCase, 0, len(corsProbes)*len(providerIDs))
+	for _, probe := range corsProbes {
+		for _, providerID := range providerIDs {
+			probe, providerID := probe, providerID
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runCORSProbe(ctx, probe, providerID)
+			})
+		}
+	}
+	return cases
+}
+
+func (r *Runner) runCORSProbe(ctx context.Context, probe corsProbe, providerID string) Result {
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(probe.ID, probe.Name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+
+	url := provider.OriginURL + probe.Path
+	headers := map[string]string{
+		"Origin":                         probe.Origin,
+		"Access-Control-Request-Method":  probe.RequestMethod,
+		"Access-Control-Request-Headers": probe.RequestHeaders,
+	}
+	resp, err := r.probe(ctx, probe.Method, url, headers)
+	if err != nil {
+		return errorResult(probe.ID, probe.Name, providerID, url, err)
+	}
+
+	checks := corsChecks(probe, resp)
+	return Result{
+		EndpointID:   probe.ID,
+		EndpointName: probe.Name,
+		ProviderID:   providerID,
+		URL:          url,
+		Status:       resp.Status,
+		StatusText:   resp.StatusText,
+		Duration:     resp.Duration.Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      flattenHeaders(resp.Header),
+		Checks:       checks,
+	}
+}
+
+func corsChecks(probe corsProbe, resp probeResponse) []Check {
+	var checks []Check
+
+	if probe.Method == http.MethodOptions {
+		checks = append(checks, Check{
+			Name:     "preflight status",
+			Expected: "2xx",
+			Actual:   strconv.Itoa(resp.Status),
+			Passed:   resp.Status >= 200 && resp.Status < 300,
+		})
+	}
+
+	allowOrigin := resp.Header.Get("Access-Control-Allow-Origin")
+	checks = append(checks, Check{
+		Name:     "allow-origin",
+		Expected: "* or " + probe.Origin,
+		Actual:   allowOrigin,
+		Passed:   allowOrigin == "*" || allowOrigin == probe.Origin,
+	})
+
+	if len(probe.AllowMethods) > 0 {
+		allowMethods := resp.Header.Get("Access-Control-Allow-Methods")
+		missing := missingTokens(allowMethods, probe.AllowMethods)
+		checks = append(checks, Check{
+			Name:     "allow-methods",
+			Expected: strings.Join(probe.AllowMethods, ", "),
+			Actual:   allowMethods,
+			Passed:   len(missing) == 0,
+		})
+	}
+
+	if probe.MaxAge != "" {
+		maxAge := resp.Header.Get("Access-Control-Max-Age")
+		checks = append(checks, Check{
+			Name:     "max-age",
+			Expected: probe.MaxAge,
+			Actual:   maxAge,
+			Passed:   maxAge == probe.MaxAge,
+		})
+	}
+
+	if len(probe.ExposeHeaders) > 0 {
+		expose := resp.Header.Get("Access-Control-Expose-Headers")
+		missing := missingTokens(expose, probe.ExposeHeaders)
+		actual := expose
+		if len(missing) > 0 {
+			actual = "missing: " + strings.Join(missing, ", ")
+		}
+		checks = append(checks, Check{
+			Name:     "expose-headers",
+			Expected: strings.Join(probe.ExposeHeaders, ", "),
+			Actual:   actual,
+			Passed:   len(missing) == 0,
+		})
+	}
+
+	return checks
+}
+
+// missingTokens returns the entries of want that do not appear in the
+// comma-separated header value, compared case-insensitively.
+func missingTokens(value string, want []string) []string {
+	present := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		present[strings.ToLower(strings.TrimSpace(part))] = true
+	}
+	var missing []string
+	for _, w := range want {
+		if !present[strings.ToLower(w)] {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
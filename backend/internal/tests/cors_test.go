@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+)
+
+func TestCORSSuiteDetectsStrippedHeaders(t *testing.T) {
+	newOrigin := func(stripExpose bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+				w.Header().Set("Access-Control-Max-Age", "86400")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if !stripExpose {
+				w.Header().Set("Access-Control-Expose-Headers", "Cache-Control, Age, ETag, Last-Modified, X-Cache, X-Cache-Status, X-Served-By, Timing-Allow-Origin, X-Cache-TTL, X-Geo-Country, X-Visitor-IP")
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	good := newOrigin(false)
+	defer good.Close()
+	stripped := newOrigin(true)
+	defer stripped.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: good.URL},
+			"arvan": {ID: "arvan", OriginURL: stripped.URL},
+		},
+	}
+
+	runner := NewRunner(cfg, providers.NewRegistry(cfg))
+	cases, err := runner.resolveSuiteCases(RunRequest{Suites: []string{"cors"}}, []string{"verge", "arvan"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cases) != len(corsProbes)*2 {
+		t.Fatalf("expected %d cases, got %d", len(corsProbes)*2, len(cases))
+	}
+
+	for _, run := range cases {
+		res := run(context.Background())
+		isGet := res.EndpointID == "cors-get-static" || res.EndpointID == "cors-get-time"
+		wantSuccess := res.ProviderID == "verge" || !isGet
+		if res.Success != wantSuccess {
+			t.Fatalf("%s/%s: expected success=%v, got checks %+v", res.ProviderID, res.EndpointID, wantSuccess, res.Checks)
+		}
+	}
+}
+
+func TestResolveSuiteCasesUnknownSuite(t *testing.T) {
+	runner := NewRunner(config.Config{}, nil)
+	if _, err := runner.resolveSuiteCases(RunRequest{Suites: []string{"nope"}}, nil); err == nil {
+		t.Fatal("expected error for unknown suite")
+	}
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultResolvers are queried when neither the run nor the environment
+// names any.
+var defaultResolvers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+
+// edgeHeaderPrefixes select the response headers that identify which edge
+// node or POP answered.
+var edgeHeaderPrefixes = []string{
+	"server", "via", "x-cache", "x-served-by", "x-edge", "x-pop", "cf-ray",
+	"ar-", "x-sid", "x-request-id", "x-amz-cf-pop", "x-verge",
+}
+
+// DNSAnswer is a single A or AAAA record.
+type DNSAnswer struct {
+	Value string `json:"value"`
+	TTL   uint32 `json:"ttl"`
+}
+
+// DNSLookup is the resolution of one host against one resolver.
+type DNSLookup struct {
+	Resolver   string      `json:"resolver"`
+	CNAMEChain []string    `json:"cnameChain,omitempty"`
+	CNAMETTLs  []uint32    `json:"cnameTtls,omitempty"`
+	A          []DNSAnswer `json:"a,omitempty"`
+	AAAA       []DNSAnswer `json:"aaaa,omitempty"`
+	AMs        int64       `json:"aMs"`
+	AAAAMs     int64       `json:"aaaaMs"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// DNSReport maps a provider host to the addresses each resolver hands out
+// and to the edge that actually answered.
+type DNSReport struct {
+	Host        string            `json:"host"`
+	Lookups     []DNSLookup       `json:"lookups"`
+	EdgeHeaders map[string]string `json:"edgeHeaders,omitempty"`
+}
+
+func dnsCases(r *Runner, req RunRequest, providerIDs []string) []testCase {
+	resolvers := req.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = r.cfg.Resolvers
+	}
+	if len(resolvers) == 0 {
+		resolvers = defaultResolvers
+	}
+
+	var cases []testCase
+	for _, providerID := range providerIDs {
+		provider, ok := r.cfg.ProviderByID(providerID)
+		if !ok {
+			continue
+		}
+		for _, host := range provider.Hosts {
+			providerID, host := providerID, host
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runDNSProbe(ctx, providerID, host, resolvers)
+			})
+		}
+	}
+	return cases
+}
+
+func (r *Runner) runDNSProbe(ctx context.Context, providerID, host string, resolvers []string) Result {
+	id, name := "dns-"+host, "DNS "+host
+	start := time.Now()
+	report := DNSReport{Host: host}
+
+	var checks []Check
+	for _, resolver := range resolvers {
+		lookup := resolveHost(ctx, withDNSPort(resolver), host)
+		report.Lookups = append(report.Lookups, lookup)
+
+		actual := lookup.Error
+		if actual == "" {
+			actual = fmt.Sprintf("%d A, %d AAAA", len(lookup.A), len(lookup.AAAA))
+		}
+		checks = append(checks, Check{
+			Name:     "resolves via " + resolver,
+			Expected: "at least one A or AAAA record",
+			Actual:   actual,
+			Passed:   lookup.Error == "" && len(lookup.A)+len(lookup.AAAA) > 0,
+		})
+	}
+
+	provider, _ := r.cfg.ProviderByID(providerID)
+	target := edgeScheme(provider.OriginURL) + "://" + host + "/probe.txt"
+	if resp, err := r.probe(ctx, http.MethodHead, target, nil); err == nil {
+		report.EdgeHeaders = edgeHeaders(resp.Header)
+	}
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          target,
+		Status:       "DNS",
+		Duration:     time.Since(start).Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      report.EdgeHeaders,
+		Checks:       checks,
+		DNS:          &report,
+	}
+}
+
+// edgeScheme returns the scheme of the provider's configured URL, so
+// HTTP-only test setups are probed over HTTP. It defaults to https.
+func edgeScheme(originURL string) string {
+	if u, err := url.Parse(originURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return u.Scheme
+	}
+	return "https"
+}
+
+func resolveHost(ctx context.Context, resolver, host string) DNSLookup {
+	lookup := DNSLookup{Resolver: resolver}
+
+	aStart := time.Now()
+	aMsg, err := queryDNS(ctx, resolver, host, dnsmessage.TypeA)
+	lookup.AMs = time.Since(aStart).Milliseconds()
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+
+	aaaaStart := time.Now()
+	aaaaMsg, err := queryDNS(ctx, resolver, host, dnsmessage.TypeAAAA)
+	lookup.AAAAMs = time.Since(aaaaStart).Milliseconds()
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+
+	for _, ans := range aMsg.Answers {
+		switch body := ans.Body.(type) {
+		case *dnsmessage.CNAMEResource:
+			lookup.CNAMEChain = append(lookup.CNAMEChain, strings.TrimSuffix(body.CNAME.String(), "."))
+			lookup.CNAMETTLs = append(lookup.CNAMETTLs, ans.Header.TTL)
+		case *dnsmessage.AResource:
+			lookup.A = append(lookup.A, DNSAnswer{Value: netip.AddrFrom4(body.A).String(), TTL: ans.Header.TTL})
+		}
+	}
+	for _, ans := range aaaaMsg.Answers {
+		if body, ok := ans.Body.(*dnsmessage.AAAAResource); ok {
+			lookup.AAAA = append(lookup.AAAA, DNSAnswer{Value: netip.AddrFrom16(body.AAAA).String(), TTL: ans.Header.TTL})
+		}
+	}
+	return lookup
+}
+
+// queryDNS sends a single recursive query over UDP. Truncated answers are
+// returned as-is; the records we need fit comfortably in 512 bytes.
+func queryDNS(ctx context.Context, resolver, host string, qtype dnsmessage.Type) (dnsmessage.Message, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", resolver)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(packet); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	buf := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return dnsmessage.Message{}, err
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:n]); err != nil {
+			return dnsmessage.Message{}, err
+		}
+		if msg.Header.ID != id {
+			continue
+		}
+		if msg.Header.RCode != dnsmessage.RCodeSuccess {
+			return msg, errors.New("dns " + strings.TrimPrefix(msg.Header.RCode.String(), "RCode"))
+		}
+		return msg, nil
+	}
+}
+
+func withDNSPort(resolver string) string {
+	if _, _, err := net.SplitHostPort(resolver); err == nil {
+		return resolver
+	}
+	return net.JoinHostPort(resolver, "53")
+}
+
+func edgeHeaders(h http.Header) map[string]string {
+	out := make(map[string]string)
+	for key, values := range h {
+		lower := strings.ToLower(key)
+		for _, prefix := range edgeHeaderPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				out[key] = strings.Join(values, ", ")
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+// serveStandInDNS answers every A query for www.example.test with a CNAME
+// to edge.cdn.test plus an A record, and AAAA queries with a single record.
+func serveStandInDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) != 1 {
+				continue
+			}
+			q := req.Questions[0]
+			edge := dnsmessage.MustNewName("edge.cdn.test.")
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.Header.ID, Response: true, RecursionAvailable: true},
+				Questions: req.Questions,
+			}
+			switch q.Type {
+			case dnsmessage.TypeA:
+				resp.Answers = []dnsmessage.Resource{
+					{
+						Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 300},
+						Body:   &dnsmessage.CNAMEResource{CNAME: edge},
+					},
+					{
+						Header: dnsmessage.ResourceHeader{Name: edge, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+						Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}},
+					},
+				}
+			case dnsmessage.TypeAAAA:
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: edge, Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+				}}
+			}
+			packet, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packet, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestResolveHostRecordsChainAndTTLs(t *testing.T) {
+	resolver := serveStandInDNS(t)
+
+	lookup := resolveHost(context.Background(), resolver, "www.example.test")
+	if lookup.Error != "" {
+		t.Fatalf("unexpected error: %s", lookup.Error)
+	}
+	if len(lookup.CNAMEChain) != 1 || lookup.CNAMEChain[0] != "edge.cdn.test" || lookup.CNAMETTLs[0] != 300 {
+		t.Fatalf("unexpected cname chain: %v %v", lookup.CNAMEChain, lookup.CNAMETTLs)
+	}
+	if len(lookup.A) != 1 || lookup.A[0].Value != "192.0.2.10" || lookup.A[0].TTL != 60 {
+		t.Fatalf("unexpected A answers: %+v", lookup.A)
+	}
+	if len(lookup.AAAA) != 1 || lookup.AAAA[0].Value != "2001:db8::1" {
+		t.Fatalf("unexpected AAAA answers: %+v", lookup.AAAA)
+	}
+}
+
+func TestEdgeHeadersKeepsPOPIdentifiers(t *testing.T) {
+	h := http.Header{}
+	h.Set("Server", "ArvanCloud")
+	h.Set("Ar-Poweredby", "Arvan Cloud (arvancloud.ir)")
+	h.Set("X-Cache", "HIT")
+	h.Set("Content-Type", "text/plain")
+
+	got := edgeHeaders(h)
+	if len(got) != 3 || got["Content-Type"] != "" {
+		t.Fatalf("unexpected edge headers: %v", got)
+	}
+}
+
+func TestDNSProbeUsesConfiguredScheme(t *testing.T) {
+	edge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "ArvanCloud")
+	}))
+	defer edge.Close()
+
+	host := strings.TrimPrefix(edge.URL, "http://")
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {ID: "arvan", OriginURL: edge.URL, Hosts: []string{host}},
+		},
+	}
+	res := NewRunner(cfg, nil).runDNSProbe(context.Background(), "arvan", host, []string{serveStandInDNS(t)})
+	if res.URL != edge.URL+"/probe.txt" || res.DNS.EdgeHeaders["Server"] != "ArvanCloud" {
+		t.Fatalf("expected the edge to be probed over http, got %s with headers %v", res.URL, res.DNS.EdgeHeaders)
+	}
+}
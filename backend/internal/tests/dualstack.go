@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// FamilyProbe is the outcome of a probe pinned to one address family.
+type FamilyProbe struct {
+	Network    string `json:"network"`
+	Success    bool   `json:"success"`
+	Status     int    `json:"status,omitempty"`
+	Duration   int64  `json:"duration"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HappyEyeballs records what the unpinned client did: which addresses the
+// resolver returned and which family won the connection race.
+type HappyEyeballs struct {
+	IPv4Addrs  int    `json:"ipv4Addrs"`
+	IPv6Addrs  int    `json:"ipv6Addrs"`
+	Chosen     string `json:"chosen,omitempty"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+}
+
+// DualStackReport puts the IPv4 and IPv6 probes of one endpoint side by
+// side.
+type DualStackReport struct {
+	IPv4          FamilyProbe   `json:"ipv4"`
+	IPv6          FamilyProbe   `json:"ipv6"`
+	HappyEyeballs HappyEyeballs `json:"happyEyeballs"`
+}
+
+// newFamilyClient returns a client whose dialer only uses the given network
+// ("tcp4" or "tcp6"), so a host with both A and AAAA records is reached over
+// the requested family or not at all.
+func newFamilyClient(network string, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// newRaceClient returns an unpinned client that dials every request, so
+// each happy-eyeballs probe races IPv6 and IPv4 instead of reusing a
+// connection left over from earlier tests.
+func newRaceClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func (r *Runner) runDualStack(ctx context.Context, url string) *DualStackReport {
+	return &DualStackReport{
+		IPv4:          familyProbe(ctx, r.ipv4Client, "tcp4", url),
+		IPv6:          familyProbe(ctx, r.ipv6Client, "tcp6", url),
+		HappyEyeballs: r.happyEyeballs(ctx, url),
+	}
+}
+
+func familyProbe(ctx context.Context, client *http.Client, network, url string) FamilyProbe {
+	out := FamilyProbe{Network: network}
+
+	var remote string
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			remote = info.Conn.RemoteAddr().String()
+		},
+	}
+	resp, err := doProbe(httptrace.WithClientTrace(ctx, trace), client, http.MethodGet, url, nil)
+	out.RemoteAddr = remote
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Status = resp.Status
+	out.Duration = resp.Duration.Milliseconds()
+	out.Success = resp.Status >= 200 && resp.Status < 400
+	return out
+}
+
+// happyEyeballs repeats the request on a fresh connection from the race
+// client, which races IPv6 and IPv4 (RFC 8305), and records the winner.
+func (r *Runner) happyEyeballs(ctx context.Context, url string) HappyEyeballs {
+	var out HappyEyeballs
+	trace := &httptrace.ClientTrace{
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			for _, addr := range info.Addrs {
+				if addr.IP.To4() != nil {
+					out.IPv4Addrs++
+				} else {
+					out.IPv6Addrs++
+				}
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			out.RemoteAddr = info.Conn.RemoteAddr().String()
+			out.Chosen = addrFamily(out.RemoteAddr)
+		},
+	}
+	_, _ = doProbe(httptrace.WithClientTrace(ctx, trace), r.raceClient, http.MethodGet, url, nil)
+	return out
+}
+
+func addrFamily(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return "ipv4"
+	default:
+		return "ipv6"
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestDualStackReportsPerFamilyOutcome(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+
+	runner := NewRunner(config.Config{}, nil)
+	report := runner.runDualStack(context.Background(), origin.URL+"/probe.txt")
+
+	if !report.IPv4.Success || report.IPv4.Status != http.StatusOK {
+		t.Fatalf("expected IPv4 probe to succeed, got %+v", report.IPv4)
+	}
+	if report.IPv6.Success || report.IPv6.Error == "" {
+		t.Fatalf("expected IPv6 probe of an IPv4 literal to fail, got %+v", report.IPv6)
+	}
+	if report.HappyEyeballs.Chosen != "ipv4" {
+		t.Fatalf("expected default client to connect over ipv4, got %+v", report.HappyEyeballs)
+	}
+}
+
+func TestHappyEyeballsDialsEveryProbe(t *testing.T) {
+	var dials atomic.Int32
+	origin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	origin.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	origin.Start()
+	defer origin.Close()
+
+	runner := NewRunner(config.Config{}, nil)
+	if _, err := doProbe(context.Background(), runner.client, http.MethodGet, origin.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if got := runner.happyEyeballs(context.Background(), origin.URL); got.Chosen != "ipv4" {
+			t.Fatalf("expected a connection over ipv4, got %+v", got)
+		}
+	}
+	if got := dials.Load(); got != 3 {
+		t.Fatalf("expected each probe to open its own connection, got %d connections", got)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type errorPageProbe struct {
+	ID     string
+	Name   string
+	Path   string
+	Status int
+}
+
+var errorPageProbes = []errorPageProbe{
+	{ID: "error-404", Name: "Error Page 404 (missing path)", Path: "/cdn-test-missing/does-not-exist.html", Status: http.StatusNotFound},
+	{ID: "error-500", Name: "Error Page 500 (origin error)", Path: "/errors/500", Status: http.StatusInternalServerError},
+	{ID: "error-502", Name: "Error Page 502 (origin error)", Path: "/errors/502", Status: http.StatusBadGateway},
+	{ID: "error-503", Name: "Error Page 503 (origin error)", Path: "/errors/503", Status: http.StatusServiceUnavailable},
+}
+
+// originBodyMarkers identify bodies generated by our nginx origin: the
+// /errors/* locations and nginx's own default error pages.
+var originBodyMarkers = []string{"origin-error-", "<center>nginx"}
+
+const (
+	bodySourceOrigin = "origin"
+	bodySourceCDN    = "cdn"
+)
+
+// ErrorPageReport records how the edge mapped an origin error.
+type ErrorPageReport struct {
+	OriginStatus     int    `json:"originStatus"`
+	EdgeStatus       int    `json:"edgeStatus"`
+	BodySource       string `json:"bodySource"`
+	CustomPageMarker string `json:"customPageMarker,omitempty"`
+	CustomPageServed bool   `json:"customPageServed,omitempty"`
+}
+
+func errorPageCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	cases := make([]testCase, 0, len(errorPageProbes)*len(providerIDs))
+	for _, probe := range errorPageProbes {
+		for _, providerID := range providerIDs {
+			probe, providerID := probe, providerID
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runErrorPageProbe(ctx, probe, providerID)
+			})
+		}
+	}
+	return cases
+}
+
+func (r *Runner) runErrorPageProbe(ctx context.Context, probe errorPageProbe, providerID string) Result {
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(probe.ID, probe.Name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+
+	url := provider.OriginURL + probe.Path
+	resp, err := r.probe(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errorResult(probe.ID, probe.Name, providerID, url, err)
+	}
+
+	report := ErrorPageReport{
+		OriginStatus: probe.Status,
+		EdgeStatus:   resp.Status,
+		BodySource:   classifyErrorBody(resp.Body),
+	}
+	checks := []Check{{
+		Name:     "status preserved",
+		Expected: strconv.Itoa(probe.Status),
+		Actual:   strconv.Itoa(resp.Status),
+		Passed:   resp.Status == probe.Status,
+	}}
+
+	// With a custom page configured for the status the edge returned, the
+	// CDN is expected to replace the body; otherwise the origin's body
+	// should pass through untouched.
+	if marker, ok := provider.ErrorPages[resp.Status]; ok {
+		report.CustomPageMarker = marker
+		report.CustomPageServed = strings.Contains(string(resp.Body), marker)
+		checks = append(checks, Check{
+			Name:     "custom error page served",
+			Expected: marker,
+			Actual:   report.BodySource + " body",
+			Passed:   report.CustomPageServed,
+		})
+	} else {
+		checks = append(checks, Check{
+			Name:     "body source",
+			Expected: bodySourceOrigin,
+			Actual:   report.BodySource,
+			Passed:   report.BodySource == bodySourceOrigin,
+		})
+	}
+
+	return Result{
+		EndpointID:   probe.ID,
+		EndpointName: probe.Name,
+		ProviderID:   providerID,
+		URL:          url,
+		Status:       resp.Status,
+		StatusText:   resp.StatusText,
+		Duration:     resp.Duration.Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      flattenHeaders(resp.Header),
+		Checks:       checks,
+		ErrorPage:    &report,
+	}
+}
+
+func classifyErrorBody(body []byte) string {
+	text := string(body)
+	for _, marker := range originBodyMarkers {
+		if strings.Contains(text, marker) {
+			return bodySourceOrigin
+		}
+	}
+	return bodySourceCDN
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestErrorPageSuiteClassifiesBodies(t *testing.T) {
+	// The "edge" passes origin 404s through but replaces 5xx with a branded
+	// 503 page.
+	edge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/errors/500" || r.URL.Path == "/errors/502" || r.URL.Path == "/errors/503" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("<h1>Edge is under maintenance</h1>"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("<html><center>nginx</center></html>"))
+	}))
+	defer edge.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: edge.URL, ErrorPages: map[int]string{503: "under maintenance"}},
+		},
+	}
+	runner := NewRunner(cfg, nil)
+
+	byID := make(map[string]Result)
+	for _, probe := range errorPageProbes {
+		byID[probe.ID] = runner.runErrorPageProbe(context.Background(), probe, "verge")
+	}
+
+	if res := byID["error-404"]; !res.Success || res.ErrorPage.BodySource != bodySourceOrigin {
+		t.Fatalf("expected origin 404 to pass through, got %+v", res.ErrorPage)
+	}
+	if res := byID["error-500"]; res.Success || res.ErrorPage.EdgeStatus != 503 || !res.ErrorPage.CustomPageServed {
+		t.Fatalf("expected 500 remapped to custom 503 page, got %+v %+v", res.ErrorPage, res.Checks)
+	}
+	if res := byID["error-503"]; !res.Success || res.ErrorPage.BodySource != bodySourceCDN {
+		t.Fatalf("expected custom 503 page to pass, got %+v %+v", res.ErrorPage, res.Checks)
+	}
+}
+
+func TestClassifyErrorBodyRecognisesOriginMarker(t *testing.T) {
+	if got := classifyErrorBody([]byte("origin-error-502\n")); got != bodySourceOrigin {
+		t.Fatalf("expected origin marker to be recognised, got %s", got)
+	}
+}
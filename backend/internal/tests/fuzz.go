@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+)
+
+// Caps for fuzz mode, applied per provider.
+const (
+	defaultFuzzCases = 200
+	maxFuzzCases     = 1000
+)
+
+// Fuzz findings. A probe without a finding got a declared 4xx or a 2xx
+// matching the spec.
+const (
+	fuzzServerError     = "server_error"
+	fuzzTimeout         = "timeout"
+	fuzzTransportError  = "error"
+	fuzzUndeclared      = "undeclared_status"
+	fuzzErrorFormat     = "error_format"
+	fuzzSchemaViolation = "schema_violation"
+)
+
+// FuzzConfig switches a run into fuzz mode: read-only API operations are
+// called with boundary and malformed values for their query parameters.
+type FuzzConfig struct {
+	// Operations limits the run to these operationIds. By default every
+	// GET operation whose only path parameter is {domain} is fuzzed.
+	Operations []string `json:"operations,omitempty"`
+	// MaxCases caps the calls made per provider.
+	MaxCases int `json:"maxCases,omitempty"`
+}
+
+// FuzzReport holds the probes of one operation.
+type FuzzReport struct {
+	OperationID string      `json:"operationId"`
+	Path        string      `json:"path"`
+	Probes      []FuzzProbe `json:"probes"`
+	Findings    int         `json:"findings"`
+}
+
+// FuzzProbe is one call with a fuzzed parameter and what it revealed.
+type FuzzProbe struct {
+	openapi.FuzzValue
+	Status   int    `json:"status,omitempty"`
+	Duration int64  `json:"duration"`
+	Finding  string `json:"finding,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+func (c FuzzConfig) normalized() FuzzConfig {
+	out := c
+	if out.MaxCases <= 0 {
+		out.MaxCases = defaultFuzzCases
+	}
+	out.MaxCases = min(out.MaxCases, maxFuzzCases)
+	return out
+}
+
+type fuzzTarget struct {
+	op     openapi.OperationRef
+	values []openapi.FuzzValue
+}
+
+// fuzzTargets picks the operations of providerID to fuzz and their values,
+// stopping once the case budget is spent.
+func (r *Runner) fuzzTargets(providerID string, cfg FuzzConfig) []fuzzTarget {
+	doc := r.registry.Spec(providerID)
+	provider, _ := r.cfg.ProviderByID(providerID)
+	wanted := make(map[string]bool, len(cfg.Operations))
+	for _, id := range cfg.Operations {
+		wanted[id] = true
+	}
+
+	var targets []fuzzTarget
+	budget := cfg.MaxCases
+	for _, entry := range r.registry.Catalog(providerID) {
+		if budget <= 0 {
+			break
+		}
+		if len(wanted) > 0 && !wanted[entry.OperationID] {
+			continue
+		}
+		if !fuzzable(entry.PathParams, provider.Domain) {
+			continue
+		}
+		op, ok := r.registry.Operation(providerID, entry.OperationID)
+		if !ok {
+			continue
+		}
+		values := doc.FuzzValues(op)
+		if len(values) == 0 {
+			continue
+		}
+		if len(values) > budget {
+			values = values[:budget]
+		}
+		budget -= len(values)
+		targets = append(targets, fuzzTarget{op: op, values: values})
+	}
+	return targets
+}
+
+// fuzzable reports whether an operation can be called without knowing
+// resource IDs: its only path parameter may be the configured domain.
+func fuzzable(pathParams []string, domain string) bool {
+	for _, name := range pathParams {
+		if name != "domain" || domain == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// runFuzz fuzzes each provider in turn and returns one Result per
+// operation. Calls go through the registry, so its rate limiter applies;
+// probes are never retried.
+func (r *Runner) runFuzz(ctx context.Context, cfg FuzzConfig, providerIDs []string, handler func(ProgressEvent)) (RunResponse, error) {
+	cfg = cfg.normalized()
+
+	plans := make(map[string][]fuzzTarget, len(providerIDs))
+	total := 0
+	for _, providerID := range providerIDs {
+		if r.registry.Spec(providerID) == nil {
+			total++
+			continue
+		}
+		plans[providerID] = r.fuzzTargets(providerID, cfg)
+		total += len(plans[providerID])
+	}
+	if total == 0 {
+		return RunResponse{}, errors.New("no fuzzable operations found")
+	}
+
+	results := make([]Result, 0, total)
+	emit := func(res Result) {
+		results = append(results, res)
+		if handler != nil {
+			copied := res
+			handler(ProgressEvent{Completed: len(results), Total: total, Result: &copied})
+		}
+	}
+
+	for _, providerID := range providerIDs {
+		targets, ok := plans[providerID]
+		if !ok {
+			emit(errorResult("fuzz", "API Fuzzing", providerID, "", errors.New("provider has no OpenAPI spec loaded")))
+			continue
+		}
+		for _, target := range targets {
+			res := r.fuzzOperation(ctx, providerID, target)
+			if ctx.Err() != nil {
+				return RunResponse{}, ctx.Err()
+			}
+			emit(res)
+		}
+	}
+	return RunResponse{Results: results}, nil
+}
+
+func (r *Runner) fuzzOperation(ctx context.Context, providerID string, target fuzzTarget) Result {
+	id := target.op.Operation.OperationID
+	report := FuzzReport{OperationID: id, Path: target.op.Path}
+	start := time.Now()
+	lastStatus := 0
+
+	for _, value := range target.values {
+		if ctx.Err() != nil {
+			break
+		}
+		params := url.Values{value.Parameter: {value.Value}}
+		callStart := time.Now()
+		resp, err := r.registry.ProbeOperation(ctx, providerID, id, params)
+		probe := FuzzProbe{
+			FuzzValue: value,
+			Status:    resp.Status,
+			Duration:  time.Since(callStart).Milliseconds(),
+		}
+		probe.Finding, probe.Detail = r.classifyFuzz(ctx, providerID, target.op, resp.Status, resp.Body, err)
+		if probe.Finding != "" {
+			report.Findings++
+		}
+		if resp.Status != 0 {
+			lastStatus = resp.Status
+		}
+		report.Probes = append(report.Probes, probe)
+	}
+
+	return Result{
+		EndpointID:   "fuzz-" + id,
+		EndpointName: "Fuzz: " + id,
+		ProviderID:   providerID,
+		URL:          target.op.Path,
+		Status:       lastStatus,
+		Duration:     time.Since(start).Milliseconds(),
+		Success:      report.Findings == 0,
+		Fuzz:         &report,
+	}
+}
+
+// classifyFuzz flags server errors, timeouts, statuses the operation does
+// not document and bodies that break the declared schema for their status.
+func (r *Runner) classifyFuzz(ctx context.Context, providerID string, op openapi.OperationRef, status int, body []byte, err error) (string, string) {
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return fuzzTimeout, err.Error()
+		}
+		return fuzzTransportError, err.Error()
+	}
+	if status >= 500 {
+		return fuzzServerError, fmt.Sprintf("status %d", status)
+	}
+
+	doc := r.registry.Spec(providerID)
+	if !doc.DeclaresStatus(op.Operation, status) {
+		return fuzzUndeclared, fmt.Sprintf("status %d is not declared by the operation", status)
+	}
+	report := doc.ValidateResponse(op, status, body)
+	if report.Valid {
+		return "", ""
+	}
+	detail := "response does not match the declared schema"
+	if len(report.Violations) > 0 {
+		v := report.Violations[0]
+		detail = v.Path + ": " + v.Message
+	}
+	if status >= 400 {
+		return fuzzErrorFormat, detail
+	}
+	return fuzzSchemaViolation, detail
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
+)
+
+const fuzzRunnerSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/domains/{domain}/records": {
+      "get": {"operationId": "records.index", "parameters": [
+        {"name": "domain", "in": "path", "required": true, "schema": {"type": "string"}},
+        {"name": "page", "in": "query", "schema": {"type": "integer"}},
+        {"name": "type", "in": "query", "schema": {"type": "string", "enum": ["a", "aaaa"]}}
+      ], "responses": {
+        "200": {"description": "OK"},
+        "422": {"content": {"application/json": {"schema": {
+          "type": "object", "required": ["message"], "properties": {"message": {"type": "string"}}
+        }}}}
+      }}
+    },
+    "/records/{id}": {
+      "get": {"operationId": "records.show", "parameters": [
+        {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+        {"name": "page", "in": "query", "schema": {"type": "integer"}}
+      ], "responses": {"200": {"description": "OK"}}}
+    }
+  }
+}`
+
+func TestFuzzModeFlagsMisbehaviour(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/domains/example.com/records" {
+			t.Errorf("operation with unknown path parameters was fuzzed: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		switch {
+		case q.Get("page") == "0":
+			w.WriteHeader(http.StatusInternalServerError)
+		case q.Get("page") == "-1":
+			time.Sleep(200 * time.Millisecond)
+		case q.Get("page") == "abc":
+			w.WriteHeader(http.StatusTeapot)
+		case q.Has("type"):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"invalid type"}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message":"The given data was invalid."}`))
+		}
+	}))
+	defer api.Close()
+
+	doc, err := openapi.Parse([]byte(fuzzRunnerSpec), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"arvan": {
+				ID:               "arvan",
+				APIBase:          api.URL,
+				Domain:           "example.com",
+				ResourceTimeouts: map[string]time.Duration{"records.index": 100 * time.Millisecond},
+			},
+		},
+	}
+	registry := providers.NewRegistry(cfg)
+	registry.SetRetryPolicy(providers.RetryPolicy{MaxAttempts: 1})
+	registry.SetSpec("arvan", doc)
+
+	resp, err := NewRunner(cfg, registry).Run(context.Background(), RunRequest{Fuzz: &FuzzConfig{}})
+	if err != nil {
+		t.Fatalf("fuzz run failed: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Fuzz == nil {
+		t.Fatalf("expected one fuzzed operation, got %+v", resp.Results)
+	}
+	res := resp.Results[0]
+	if res.Success {
+		t.Fatal("expected findings to fail the operation")
+	}
+
+	findings := make(map[string]string)
+	for _, probe := range res.Fuzz.Probes {
+		findings[probe.Parameter+"="+probe.Value] = probe.Finding
+	}
+	for query, want := range map[string]string{
+		"page=0":                fuzzServerError,
+		"page=-1":               fuzzTimeout,
+		"page=abc":              fuzzUndeclared,
+		"type=__invalid_enum__": fuzzErrorFormat,
+		"page=1000000":          "",
+	} {
+		if got, ok := findings[query]; !ok || got != want {
+			t.Errorf("%s: expected finding %q, got %q (probed %v)", query, want, got, ok)
+		}
+	}
+}
+
+func TestFuzzModeHonoursCaseBudget(t *testing.T) {
+	doc, err := openapi.Parse([]byte(fuzzRunnerSpec), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{"arvan": {ID: "arvan", Domain: "example.com"}},
+	}
+	registry := providers.NewRegistry(cfg)
+	registry.SetSpec("arvan", doc)
+
+	targets := NewRunner(cfg, registry).fuzzTargets("arvan", FuzzConfig{MaxCases: 3}.normalized())
+	if len(targets) != 1 || len(targets[0].values) != 3 {
+		t.Fatalf("expected 3 cases for one operation, got %+v", targets)
+	}
+}
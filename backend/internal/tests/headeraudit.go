@@ -0,0 +1,306 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// auditPaths are probed by the headers suite; /health is included because
+// nginx adds origin address headers there.
+var auditPaths = []string{"/", "/health"}
+
+// leakHeaders expose origin internals and should be stripped by the edge.
+var leakHeaders = []string{
+	"X-Origin-Server-Addr", "X-Origin-Server-Name", "X-Powered-By",
+	"X-AspNet-Version", "X-AspNetMvc-Version",
+}
+
+var versionPattern = regexp.MustCompile(`/\d`)
+
+const (
+	hstsMinMaxAge = 15552000 // 180 days
+	leakPenalty   = 10
+)
+
+// HeaderFinding is the verdict on one security header.
+type HeaderFinding struct {
+	Header string `json:"header"`
+	Value  string `json:"value,omitempty"`
+	Points int    `json:"points"`
+	Max    int    `json:"max"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// HeaderAudit scores the security headers of a single response.
+type HeaderAudit struct {
+	Score    int             `json:"score"`
+	Grade    string          `json:"grade"`
+	Findings []HeaderFinding `json:"findings"`
+	Leaks    []string        `json:"leaks,omitempty"`
+}
+
+// SecurityGrade aggregates the header audits of one provider over a run.
+type SecurityGrade struct {
+	ProviderID string   `json:"providerId"`
+	Responses  int      `json:"responses"`
+	Score      int      `json:"score"`
+	Grade      string   `json:"grade"`
+	Leaks      []string `json:"leaks,omitempty"`
+}
+
+// auditHeaders scores h out of 100 across HSTS (30), CSP (25),
+// X-Content-Type-Options (15), X-Frame-Options (10), Referrer-Policy (10)
+// and Permissions-Policy (10), minus 10 per information-leaking header.
+func auditHeaders(h http.Header) HeaderAudit {
+	findings := []HeaderFinding{
+		auditHSTS(h.Get("Strict-Transport-Security")),
+		auditCSP(h.Get("Content-Security-Policy")),
+		auditExact("X-Content-Type-Options", h.Get("X-Content-Type-Options"), 15, "nosniff"),
+		auditFrameOptions(h),
+		auditExact("Referrer-Policy", h.Get("Referrer-Policy"), 10,
+			"no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin"),
+		auditPresent("Permissions-Policy", h.Get("Permissions-Policy"), 10),
+	}
+
+	score := 0
+	for _, f := range findings {
+		score += f.Points
+	}
+
+	var leaks []string
+	for _, name := range leakHeaders {
+		if v := h.Get(name); v != "" {
+			leaks = append(leaks, name+": "+v)
+		}
+	}
+	if server := h.Get("Server"); versionPattern.MatchString(server) {
+		leaks = append(leaks, "Server: "+server)
+	}
+	score = max(0, score-leakPenalty*len(leaks))
+
+	return HeaderAudit{Score: score, Grade: gradeFor(score), Findings: findings, Leaks: leaks}
+}
+
+func auditHSTS(value string) HeaderFinding {
+	f := HeaderFinding{Header: "Strict-Transport-Security", Value: value, Max: 30}
+	if value == "" {
+		f.Detail = "missing"
+		return f
+	}
+
+	maxAge := -1
+	var subdomains, preload bool
+	for _, directive := range strings.Split(value, ";") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case strings.HasPrefix(directive, "max-age="):
+			var err error
+			if maxAge, err = strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`)); err != nil {
+				maxAge = -1
+			}
+		case directive == "includesubdomains":
+			subdomains = true
+		case directive == "preload":
+			preload = true
+		}
+	}
+	// The directives only matter while HSTS is on; max-age=0 tells
+	// browsers to forget the host's policy.
+	switch {
+	case maxAge == 0:
+		f.Detail = "max-age=0 disables HSTS"
+		return f
+	case maxAge < 0:
+		f.Detail = "invalid max-age"
+		return f
+	}
+
+	var details []string
+	if maxAge >= hstsMinMaxAge {
+		f.Points += 20
+	} else {
+		f.Points += 10
+		details = append(details, "max-age below 180 days")
+	}
+	if subdomains {
+		f.Points += 5
+	} else {
+		details = append(details, "no includeSubDomains")
+	}
+	if preload {
+		f.Points += 5
+	} else {
+		details = append(details, "no preload")
+	}
+	f.Detail = strings.Join(details, ", ")
+	return f
+}
+
+func auditCSP(value string) HeaderFinding {
+	f := HeaderFinding{Header: "Content-Security-Policy", Value: value, Max: 25}
+	lower := strings.ToLower(value)
+	switch {
+	case value == "":
+		f.Detail = "missing"
+	case strings.Contains(lower, "'unsafe-inline'") || strings.Contains(lower, "'unsafe-eval'"):
+		f.Points = 15
+		f.Detail = "allows unsafe-inline or unsafe-eval"
+	case !strings.Contains(lower, "default-src") && !strings.Contains(lower, "script-src"):
+		f.Points = 15
+		f.Detail = "no default-src or script-src"
+	default:
+		f.Points = 25
+	}
+	return f
+}
+
+func auditFrameOptions(h http.Header) HeaderFinding {
+	value := h.Get("X-Frame-Options")
+	f := auditExact("X-Frame-Options", value, 10, "deny", "sameorigin")
+	if f.Points == 0 && strings.Contains(strings.ToLower(h.Get("Content-Security-Policy")), "frame-ancestors") {
+		f.Points = f.Max
+		f.Detail = "covered by CSP frame-ancestors"
+	}
+	return f
+}
+
+func auditExact(header, value string, points int, accepted ...string) HeaderFinding {
+	f := HeaderFinding{Header: header, Value: value, Max: points}
+	if value == "" {
+		f.Detail = "missing"
+		return f
+	}
+	for _, a := range accepted {
+		if strings.EqualFold(strings.TrimSpace(value), a) {
+			f.Points = points
+			return f
+		}
+	}
+	f.Detail = "unexpected value"
+	return f
+}
+
+func auditPresent(header, value string, points int) HeaderFinding {
+	f := HeaderFinding{Header: header, Value: value, Max: points}
+	if value == "" {
+		f.Detail = "missing"
+		return f
+	}
+	f.Points = points
+	return f
+}
+
+func gradeFor(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+func headerAuditCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	cases := make([]testCase, 0, len(auditPaths)*len(providerIDs))
+	for _, path := range auditPaths {
+		for _, providerID := range providerIDs {
+			path, providerID := path, providerID
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runHeaderAudit(ctx, path, providerID)
+			})
+		}
+	}
+	return cases
+}
+
+func (r *Runner) runHeaderAudit(ctx context.Context, path, providerID string) Result {
+	id, name := "headers"+strings.ReplaceAll(path, "/", "-"), "Security Headers "+path
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+
+	url := provider.OriginURL + path
+	resp, err := r.probe(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errorResult(id, name, providerID, url, err)
+	}
+
+	audit := auditHeaders(resp.Header)
+	checks := make([]Check, 0, len(audit.Findings)+1)
+	for _, f := range audit.Findings {
+		checks = append(checks, Check{
+			Name:     f.Header,
+			Expected: strconv.Itoa(f.Max),
+			Actual:   strconv.Itoa(f.Points),
+			Passed:   f.Points == f.Max,
+		})
+	}
+	checks = append(checks, Check{
+		Name:     "no information-leaking headers",
+		Expected: "none",
+		Actual:   strings.Join(audit.Leaks, "; "),
+		Passed:   len(audit.Leaks) == 0,
+	})
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          url,
+		Status:       resp.Status,
+		StatusText:   resp.StatusText,
+		Duration:     resp.Duration.Milliseconds(),
+		Success:      audit.Grade == "A" || audit.Grade == "B",
+		Headers:      flattenHeaders(resp.Header),
+		Checks:       checks,
+		HeaderAudit:  &audit,
+	}
+}
+
+// securityGrades averages the header audits in results per provider, in
+// the order providers first appear.
+func securityGrades(results []Result) []SecurityGrade {
+	var order []string
+	sums := make(map[string]int)
+	counts := make(map[string]int)
+	leaks := make(map[string]map[string]bool)
+
+	for _, res := range results {
+		if res.HeaderAudit == nil {
+			continue
+		}
+		if counts[res.ProviderID] == 0 {
+			order = append(order, res.ProviderID)
+			leaks[res.ProviderID] = make(map[string]bool)
+		}
+		counts[res.ProviderID]++
+		sums[res.ProviderID] += res.HeaderAudit.Score
+		for _, l := range res.HeaderAudit.Leaks {
+			leaks[res.ProviderID][l] = true
+		}
+	}
+
+	out := make([]SecurityGrade, 0, len(order))
+	for _, id := range order {
+		score := sums[id] / counts[id]
+		grade := SecurityGrade{ProviderID: id, Responses: counts[id], Score: score, Grade: gradeFor(score)}
+		for l := range leaks[id] {
+			grade.Leaks = append(grade.Leaks, l)
+		}
+		sort.Strings(grade.Leaks)
+		out = append(out, grade)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAuditHeadersScoresHardenedResponse(t *testing.T) {
+	h := http.Header{}
+	h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	h.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	h.Set("Permissions-Policy", "geolocation=()")
+	h.Set("Server", "cloudflare")
+
+	audit := auditHeaders(h)
+	if audit.Score != 100 || audit.Grade != "A" || len(audit.Leaks) != 0 {
+		t.Fatalf("expected perfect score, got %+v", audit)
+	}
+}
+
+func TestAuditHSTSZeroMaxAgeScoresNothing(t *testing.T) {
+	f := auditHSTS("max-age=0; includeSubDomains; preload")
+	if f.Points != 0 || f.Detail != "max-age=0 disables HSTS" {
+		t.Fatalf("expected max-age=0 to score 0, got %d (%s)", f.Points, f.Detail)
+	}
+	if f := auditHSTS("max-age=86400; includeSubDomains"); f.Points != 15 {
+		t.Fatalf("expected a short max-age with includeSubDomains to score 15, got %d (%s)", f.Points, f.Detail)
+	}
+}
+
+func TestAuditHeadersFlagsLeaks(t *testing.T) {
+	// Mirrors the headers nginx sets on /health.
+	h := http.Header{}
+	h.Set("Server", "nginx/1.25.3")
+	h.Set("X-Origin-Server-Addr", "172.18.0.3")
+	h.Set("X-Origin-Server-Name", "cdn-test-nginx")
+	h.Set("X-Frame-Options", "SAMEORIGIN")
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	audit := auditHeaders(h)
+	if len(audit.Leaks) != 3 {
+		t.Fatalf("expected 3 leaks, got %v", audit.Leaks)
+	}
+	if audit.Score != 0 || audit.Grade != "F" {
+		t.Fatalf("expected leaks to sink the score, got %d (%s)", audit.Score, audit.Grade)
+	}
+}
+
+func TestSecurityGradesAveragesPerProvider(t *testing.T) {
+	results := []Result{
+		{ProviderID: "verge", HeaderAudit: &HeaderAudit{Score: 100}},
+		{ProviderID: "verge", HeaderAudit: &HeaderAudit{Score: 80}},
+		{ProviderID: "arvan", HeaderAudit: &HeaderAudit{Score: 50, Leaks: []string{"Server: nginx/1.25"}}},
+		{ProviderID: "api"},
+	}
+	grades := securityGrades(results)
+	if len(grades) != 2 {
+		t.Fatalf("expected two providers, got %+v", grades)
+	}
+	if grades[0].ProviderID != "verge" || grades[0].Score != 90 || grades[0].Grade != "A" {
+		t.Fatalf("unexpected verge grade: %+v", grades[0])
+	}
+	if grades[1].Grade != "F" || len(grades[1].Leaks) != 1 {
+		t.Fatalf("unexpected arvan grade: %+v", grades[1])
+	}
+}
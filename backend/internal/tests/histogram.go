@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Histogram records latencies in microseconds with two significant digits
+// of precision, in the spirit of HdrHistogram: memory stays bounded by the
+// number of distinct magnitudes while percentiles remain within 1%.
+type Histogram struct {
+	counts map[int64]int64
+	total  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+// HistogramBucket is one non-empty bucket; UpToMs is its inclusive upper
+// bound in milliseconds.
+type HistogramBucket struct {
+	UpToMs float64 `json:"upToMs"`
+	Count  int64   `json:"count"`
+}
+
+// HistogramSummary is the JSON form of a Histogram.
+type HistogramSummary struct {
+	Count   int64             `json:"count"`
+	MinMs   float64           `json:"minMs"`
+	MeanMs  float64           `json:"meanMs"`
+	P50Ms   float64           `json:"p50Ms"`
+	P90Ms   float64           `json:"p90Ms"`
+	P95Ms   float64           `json:"p95Ms"`
+	P99Ms   float64           `json:"p99Ms"`
+	P999Ms  float64           `json:"p999Ms"`
+	MaxMs   float64           `json:"maxMs"`
+	Buckets []HistogramBucket `json:"buckets,omitempty"`
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{counts: make(map[int64]int64)}
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	us := d.Microseconds()
+	if us < 0 {
+		us = 0
+	}
+	h.counts[bucketFor(us)]++
+	h.total++
+	h.sum += us
+	if h.total == 1 || us < h.min {
+		h.min = us
+	}
+	if us > h.max {
+		h.max = us
+	}
+}
+
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Percentile returns the bucket upper bound at or below which p percent of
+// the recorded values fall.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	keys := h.sortedKeys()
+	rank := int64(math.Ceil(p / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for _, k := range keys {
+		seen += h.counts[k]
+		if seen >= rank {
+			return time.Duration(min(k, h.max)) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
+
+func (h *Histogram) Summary(withBuckets bool) HistogramSummary {
+	if h.total == 0 {
+		return HistogramSummary{}
+	}
+	out := HistogramSummary{
+		Count:  h.total,
+		MinMs:  usToMs(h.min),
+		MeanMs: usToMs(h.sum / h.total),
+		P50Ms:  durToMs(h.Percentile(50)),
+		P90Ms:  durToMs(h.Percentile(90)),
+		P95Ms:  durToMs(h.Percentile(95)),
+		P99Ms:  durToMs(h.Percentile(99)),
+		P999Ms: durToMs(h.Percentile(99.9)),
+		MaxMs:  usToMs(h.max),
+	}
+	if withBuckets {
+		for _, k := range h.sortedKeys() {
+			out.Buckets = append(out.Buckets, HistogramBucket{UpToMs: usToMs(k), Count: h.counts[k]})
+		}
+	}
+	return out
+}
+
+func (h *Histogram) sortedKeys() []int64 {
+	keys := make([]int64, 0, len(h.counts))
+	for k := range h.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// bucketFor rounds v up to two significant digits, e.g. 1234 -> 1300.
+func bucketFor(v int64) int64 {
+	if v < 100 {
+		return v
+	}
+	scale := int64(1)
+	for v/scale >= 100 {
+		scale *= 10
+	}
+	return (v + scale - 1) / scale * scale
+}
+
+func usToMs(us int64) float64 {
+	return math.Round(float64(us)/10) / 100
+}
+
+func durToMs(d time.Duration) float64 {
+	return usToMs(d.Microseconds())
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	hotlinkPath           = "/protected/protected.txt"
+	hotlinkForeignReferer = "https://hotlink.invalid/gallery.html"
+)
+
+// HotlinkReport summarises how a provider edge treats the Referer header on
+// the nginx valid_referers location.
+type HotlinkReport struct {
+	RefererForwarded bool `json:"refererForwarded"`
+	CacheLeak        bool `json:"cacheLeak"`
+}
+
+func hotlinkCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	cases := make([]testCase, 0, len(providerIDs))
+	for _, providerID := range providerIDs {
+		providerID := providerID
+		cases = append(cases, func(ctx context.Context) Result {
+			return r.runHotlinkTest(ctx, providerID)
+		})
+	}
+	return cases
+}
+
+// runHotlinkTest requests the protected file with no Referer, an allowed one
+// and a foreign one, in that order, so the foreign request is the one that
+// can hit a cache entry populated by the allowed requests.
+func (r *Runner) runHotlinkTest(ctx context.Context, providerID string) Result {
+	const id, name = "hotlink", "Hotlink Protection"
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+
+	target := provider.OriginURL + hotlinkPath
+	allowedReferer := "https://" + allowedRefererHost(provider.Hosts, provider.OriginURL) + "/"
+
+	steps := []struct {
+		name    string
+		referer string
+		want    int
+	}{
+		{name: "no referer", referer: "", want: http.StatusOK},
+		{name: "allowed referer", referer: allowedReferer, want: http.StatusOK},
+		{name: "foreign referer", referer: hotlinkForeignReferer, want: http.StatusForbidden},
+	}
+
+	var (
+		checks  []Check
+		total   time.Duration
+		last    probeResponse
+		foreign probeResponse
+	)
+	for _, step := range steps {
+		resp, err := r.probe(ctx, http.MethodGet, target, map[string]string{"Referer": step.referer})
+		if err != nil {
+			return errorResult(id, name, providerID, target, err)
+		}
+		total += resp.Duration
+		last = resp
+		if step.referer == hotlinkForeignReferer {
+			foreign = resp
+		}
+		checks = append(checks, Check{
+			Name:     step.name,
+			Expected: strconv.Itoa(step.want),
+			Actual:   strconv.Itoa(resp.Status),
+			Passed:   resp.Status == step.want,
+		})
+	}
+
+	report := HotlinkReport{RefererForwarded: foreign.Status == http.StatusForbidden}
+	if foreign.Status == http.StatusOK && isCacheHit(foreign.Header) {
+		// The origin never saw this request: the edge cache ignored the
+		// referer, and forwarding stays unproven for this round.
+		report.CacheLeak = true
+	}
+	checks = append(checks, Check{
+		Name:     "cached 200 leaked to foreign referer",
+		Expected: "false",
+		Actual:   strconv.FormatBool(report.CacheLeak),
+		Passed:   !report.CacheLeak,
+	})
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          target,
+		Status:       last.Status,
+		StatusText:   last.StatusText,
+		Duration:     total.Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      flattenHeaders(last.Header),
+		Checks:       checks,
+		Hotlink:      &report,
+	}
+}
+
+func allowedRefererHost(hosts []string, originURL string) string {
+	if len(hosts) > 0 && hosts[0] != "" {
+		return hosts[0]
+	}
+	if u, err := url.Parse(originURL); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
+// isCacheHit reports whether the response headers indicate it was served
+// from an edge cache rather than fetched from the origin.
+func isCacheHit(h http.Header) bool {
+	for _, key := range []string{"X-Cache", "X-Cache-Status", "CF-Cache-Status"} {
+		if strings.Contains(strings.ToUpper(h.Get(key)), "HIT") {
+			return true
+		}
+	}
+	if age, err := strconv.Atoi(strings.TrimSpace(h.Get("Age"))); err == nil && age > 0 {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestHotlinkSuiteDetectsCacheLeak(t *testing.T) {
+	// A naive edge that caches the first 200 and serves it to everyone.
+	var cached bool
+	leaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cached {
+			w.Header().Set("X-Cache", "HIT")
+			w.Header().Set("Age", "3")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		cached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer leaky.Close()
+
+	strict := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ref := r.Header.Get("Referer")
+		if ref != "" && !strings.Contains(ref, "example.com") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer strict.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: strict.URL, Hosts: []string{"example.com"}},
+			"arvan": {ID: "arvan", OriginURL: leaky.URL, Hosts: []string{"example.com"}},
+		},
+	}
+	runner := NewRunner(cfg, nil)
+
+	ok := runner.runHotlinkTest(context.Background(), "verge")
+	if !ok.Success || ok.Hotlink == nil || !ok.Hotlink.RefererForwarded || ok.Hotlink.CacheLeak {
+		t.Fatalf("expected strict edge to pass, got %+v %+v", ok.Checks, ok.Hotlink)
+	}
+
+	leak := runner.runHotlinkTest(context.Background(), "arvan")
+	if leak.Success || leak.Hotlink == nil || !leak.Hotlink.CacheLeak {
+		t.Fatalf("expected cache leak to be reported, got %+v %+v", leak.Checks, leak.Hotlink)
+	}
+}
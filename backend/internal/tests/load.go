@@ -0,0 +1,309 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Hard caps for load mode, applied per provider. The duration cap stays
+// below the 5 minute request timeout of the /tests/run handlers.
+const (
+	maxLoadRPS      = 200
+	maxLoadDuration = 4 * time.Minute
+	maxLoadInFlight = 256
+)
+
+// LoadProfile switches a run into load mode: instead of functional rounds,
+// each provider receives requests at a target rate for a fixed duration.
+type LoadProfile struct {
+	RPS             int      `json:"rps"`
+	DurationSeconds int      `json:"duration"`
+	RampUpSeconds   int      `json:"rampUp"`
+	Endpoints       []string `json:"endpoints"`
+	MaxInFlight     int      `json:"maxInFlight"`
+}
+
+// LoadReport is the outcome of load mode for one provider.
+type LoadReport struct {
+	TargetRPS     int              `json:"targetRps"`
+	Sent          int64            `json:"sent"`
+	Completed     int64            `json:"completed"`
+	Errors        int64            `json:"errors"`
+	Dropped       int64            `json:"dropped"`
+	ErrorRate     float64          `json:"errorRate"`
+	ThroughputRPS float64          `json:"throughputRps"`
+	BytesReceived int64            `json:"bytesReceived"`
+	StatusCodes   map[int]int64    `json:"statusCodes,omitempty"`
+	Latency       HistogramSummary `json:"latency"`
+	Timeline      []LoadInterval   `json:"timeline,omitempty"`
+}
+
+// LoadInterval holds the counters of one second of a load run. It is also
+// streamed as it closes, through ProgressEvent.Load.
+type LoadInterval struct {
+	ProviderID    string  `json:"providerId"`
+	Second        int     `json:"second"`
+	Sent          int64   `json:"sent"`
+	Completed     int64   `json:"completed"`
+	Errors        int64   `json:"errors"`
+	ErrorRate     float64 `json:"errorRate"`
+	ThroughputRPS float64 `json:"throughputRps"`
+	P50Ms         float64 `json:"p50Ms"`
+	P99Ms         float64 `json:"p99Ms"`
+}
+
+func (p LoadProfile) normalized() LoadProfile {
+	out := p
+	if out.RPS <= 0 {
+		out.RPS = 10
+	}
+	if out.DurationSeconds <= 0 {
+		out.DurationSeconds = 30
+	}
+	if out.RampUpSeconds < 0 {
+		out.RampUpSeconds = 0
+	}
+	if out.MaxInFlight <= 0 {
+		out.MaxInFlight = 64
+	}
+	if len(out.Endpoints) == 0 {
+		out.Endpoints = []string{"small"}
+	}
+	out.RPS = min(out.RPS, maxLoadRPS)
+	out.DurationSeconds = min(out.DurationSeconds, int(maxLoadDuration/time.Second))
+	out.RampUpSeconds = min(out.RampUpSeconds, out.DurationSeconds)
+	out.MaxInFlight = min(out.MaxInFlight, maxLoadInFlight)
+	return out
+}
+
+// rateAt returns the arrival rate at elapsed time t, ramping linearly from
+// one request per second to the target over the ramp-up period.
+func (p LoadProfile) rateAt(t time.Duration) float64 {
+	ramp := time.Duration(p.RampUpSeconds) * time.Second
+	if ramp <= 0 || t >= ramp {
+		return float64(p.RPS)
+	}
+	return max(1, float64(p.RPS)*float64(t)/float64(ramp))
+}
+
+func resolveLoadEndpoints(ids []string) ([]Endpoint, error) {
+	out := make([]Endpoint, 0, len(ids))
+	for _, id := range ids {
+		found := false
+		for _, ep := range frontendEndpoints {
+			if ep.ID == id {
+				out = append(out, ep)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown load endpoint: %s", id)
+		}
+	}
+	return out, nil
+}
+
+// runLoad drives every provider concurrently with its own open-model
+// scheduler and returns one Result per provider.
+func (r *Runner) runLoad(ctx context.Context, profile LoadProfile, providerIDs []string, handler func(ProgressEvent)) (RunResponse, error) {
+	profile = profile.normalized()
+	endpoints, err := resolveLoadEndpoints(profile.Endpoints)
+	if err != nil {
+		return RunResponse{}, err
+	}
+
+	var (
+		mu        sync.Mutex
+		completed int
+		wg        sync.WaitGroup
+	)
+	emit := func(ev ProgressEvent) {
+		if handler == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		ev.Completed = completed
+		ev.Total = len(providerIDs)
+		handler(ev)
+	}
+
+	results := make([]Result, len(providerIDs))
+	for i, providerID := range providerIDs {
+		wg.Add(1)
+		go func(i int, providerID string) {
+			defer wg.Done()
+			res := r.runProviderLoad(ctx, profile, endpoints, providerID, func(iv LoadInterval) {
+				emit(ProgressEvent{Load: &iv})
+			})
+			results[i] = res
+
+			mu.Lock()
+			completed++
+			mu.Unlock()
+			emit(ProgressEvent{Result: &res})
+		}(i, providerID)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return RunResponse{}, ctx.Err()
+	}
+	return RunResponse{Results: results}, nil
+}
+
+type loadCounters struct {
+	sent, completed, errors int64
+	latency                 *Histogram
+}
+
+func newLoadCounters() loadCounters {
+	return loadCounters{latency: NewHistogram()}
+}
+
+// runProviderLoad schedules arrivals on a clock that does not wait for
+// responses (an open model), so a slow edge builds up in-flight requests
+// instead of silently lowering the offered load. Arrivals beyond
+// MaxInFlight are counted as dropped.
+func (r *Runner) runProviderLoad(ctx context.Context, profile LoadProfile, endpoints []Endpoint, providerID string, onInterval func(LoadInterval)) Result {
+	const id, name = "load", "Load Test"
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+
+	duration := time.Duration(profile.DurationSeconds) * time.Second
+	loadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	report := LoadReport{TargetRPS: profile.RPS, StatusCodes: make(map[int]int64)}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		total    = newLoadCounters()
+		interval = newLoadCounters()
+		second   int
+	)
+	slots := make(chan struct{}, profile.MaxInFlight)
+	start := time.Now()
+
+	flush := func() {
+		mu.Lock()
+		second++
+		iv := LoadInterval{
+			ProviderID:    providerID,
+			Second:        second,
+			Sent:          interval.sent,
+			Completed:     interval.completed,
+			Errors:        interval.errors,
+			ErrorRate:     ratio(interval.errors, interval.completed),
+			ThroughputRPS: float64(interval.completed),
+			P50Ms:         durToMs(interval.latency.Percentile(50)),
+			P99Ms:         durToMs(interval.latency.Percentile(99)),
+		}
+		report.Timeline = append(report.Timeline, iv)
+		interval = newLoadCounters()
+		mu.Unlock()
+		if onInterval != nil {
+			onInterval(iv)
+		}
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	next := time.NewTimer(0)
+	defer next.Stop()
+	seq := 0
+
+schedule:
+	for {
+		select {
+		case <-loadCtx.Done():
+			break schedule
+		case <-ticker.C:
+			flush()
+		case <-next.C:
+			elapsed := time.Since(start)
+			next.Reset(time.Duration(float64(time.Second) / profile.rateAt(elapsed)))
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				mu.Lock()
+				report.Dropped++
+				mu.Unlock()
+				continue
+			}
+
+			endpoint := endpoints[seq%len(endpoints)]
+			seq++
+			mu.Lock()
+			total.sent++
+			interval.sent++
+			mu.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				resp, err := r.probe(ctx, http.MethodGet, provider.OriginURL+endpoint.Path, nil)
+
+				mu.Lock()
+				defer mu.Unlock()
+				total.completed++
+				interval.completed++
+				if err != nil || resp.Status >= 400 {
+					total.errors++
+					interval.errors++
+				}
+				if err != nil {
+					return
+				}
+				report.StatusCodes[resp.Status]++
+				report.BytesReceived += int64(len(resp.Body))
+				total.latency.Record(resp.Duration)
+				interval.latency.Record(resp.Duration)
+			}()
+		}
+	}
+	wg.Wait()
+	if interval.sent > 0 || interval.completed > 0 {
+		flush()
+	}
+
+	if ctx.Err() != nil {
+		return errorResult(id, name, providerID, provider.OriginURL, ctx.Err())
+	}
+
+	elapsed := time.Since(start)
+	report.Sent = total.sent
+	report.Completed = total.completed
+	report.Errors = total.errors
+	report.ErrorRate = ratio(total.errors, total.completed)
+	report.ThroughputRPS = float64(total.completed) / elapsed.Seconds()
+	report.Latency = total.latency.Summary(true)
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          provider.OriginURL,
+		Status:       "LOAD",
+		Duration:     elapsed.Milliseconds(),
+		Success:      report.Completed > 0 && report.ErrorRate < 0.01,
+		Load:         &report,
+	}
+}
+
+func ratio(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
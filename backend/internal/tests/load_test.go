@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	p50 := h.Percentile(50)
+	if p50 < 495*time.Millisecond || p50 > 505*time.Millisecond {
+		t.Fatalf("expected p50 near 500ms, got %s", p50)
+	}
+	p99 := h.Percentile(99)
+	if p99 < 985*time.Millisecond || p99 > 1000*time.Millisecond {
+		t.Fatalf("expected p99 near 990ms, got %s", p99)
+	}
+	if sum := h.Summary(false); sum.Count != 1000 || sum.MaxMs != 1000 || sum.MinMs != 1 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+}
+
+func TestRunnerLoadModeStreamsIntervals(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer origin.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {ID: "verge", OriginURL: origin.URL},
+			"arvan": {ID: "arvan", OriginURL: origin.URL},
+		},
+	}
+	runner := NewRunner(cfg, nil)
+
+	var (
+		mu        sync.Mutex
+		intervals int
+		finals    int
+	)
+	resp, err := runner.RunWithProgress(context.Background(), RunRequest{
+		Load: &LoadProfile{RPS: 40, DurationSeconds: 2, RampUpSeconds: 1},
+	}, func(ev ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ev.Load != nil {
+			intervals++
+		}
+		if ev.Result != nil {
+			finals++
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.Results) != 2 || finals != 2 {
+		t.Fatalf("expected one result per provider, got %d results and %d events", len(resp.Results), finals)
+	}
+	if intervals < 2 {
+		t.Fatalf("expected streamed intervals, got %d", intervals)
+	}
+	for _, res := range resp.Results {
+		if res.Load == nil || res.Load.Completed == 0 || res.Load.Errors != 0 {
+			t.Fatalf("unexpected load report for %s: %+v", res.ProviderID, res.Load)
+		}
+		if res.Load.Sent >= 80 {
+			t.Fatalf("expected ramp-up to reduce offered load below 80, got %d", res.Load.Sent)
+		}
+		if res.Load.Latency.Count != res.Load.Completed {
+			t.Fatalf("expected every completion in the histogram, got %+v", res.Load.Latency)
+		}
+	}
+}
+
+func TestRunnerLoadModeRejectsUnknownEndpoint(t *testing.T) {
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{"verge": {ID: "verge", OriginURL: "http://127.0.0.1:1"}},
+	}
+	_, err := NewRunner(cfg, nil).Run(context.Background(), RunRequest{Load: &LoadProfile{Endpoints: []string{"missing"}}})
+	if err == nil {
+		t.Fatal("expected error for unknown load endpoint")
+	}
+}
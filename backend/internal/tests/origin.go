@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+// originPaths are probed both through the edge and straight at the origin.
+var originPaths = []string{"/", "/probe.txt", "/large-probe.txt"}
+
+// volatileHeaders differ between any two responses and are left out of the
+// edge/origin header diff.
+var volatileHeaders = map[string]bool{
+	"Date":              true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Content-Length":    true,
+}
+
+// OriginComparison contrasts the same path fetched through the provider
+// edge and directly from the origin.
+type OriginComparison struct {
+	OriginAddr       string   `json:"originAddr"`
+	EdgeStatus       int      `json:"edgeStatus"`
+	OriginStatus     int      `json:"originStatus"`
+	EdgeMs           int64    `json:"edgeMs"`
+	OriginMs         int64    `json:"originMs"`
+	DeltaMs          int64    `json:"deltaMs"`
+	AddedHeaders     []string `json:"addedHeaders,omitempty"`
+	StrippedHeaders  []string `json:"strippedHeaders,omitempty"`
+	ChangedHeaders   []string `json:"changedHeaders,omitempty"`
+	BodyEqual        bool     `json:"bodyEqual"`
+	EdgeBodySHA256   string   `json:"edgeBodySha256,omitempty"`
+	OriginBodySHA256 string   `json:"originBodySha256,omitempty"`
+}
+
+func originCases(r *Runner, _ RunRequest, providerIDs []string) []testCase {
+	cases := make([]testCase, 0, len(originPaths)*len(providerIDs))
+	for _, path := range originPaths {
+		for _, providerID := range providerIDs {
+			path, providerID := path, providerID
+			cases = append(cases, func(ctx context.Context) Result {
+				return r.runOriginComparison(ctx, path, providerID)
+			})
+		}
+	}
+	return cases
+}
+
+func (r *Runner) runOriginComparison(ctx context.Context, path, providerID string) Result {
+	id, name := "origin-compare"+path, "Origin vs Edge "+path
+
+	provider, ok := r.cfg.ProviderByID(providerID)
+	if !ok || provider.OriginURL == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin URL not configured"))
+	}
+	if provider.OriginAddr == "" {
+		return errorResult(id, name, providerID, "", errors.New("provider origin address not configured"))
+	}
+
+	target := provider.OriginURL + path
+	edge, err := r.probe(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return errorResult(id, name, providerID, target, err)
+	}
+
+	direct, err := directProbe(ctx, provider, target, r.client.Timeout)
+	if err != nil {
+		return errorResult(id, name, providerID, target, err)
+	}
+
+	cmp := compareResponses(edge, direct)
+	cmp.OriginAddr = provider.OriginAddr
+
+	checks := []Check{
+		{
+			Name:     "status",
+			Expected: strconv.Itoa(direct.Status),
+			Actual:   strconv.Itoa(edge.Status),
+			Passed:   edge.Status == direct.Status,
+		},
+		{
+			Name:     "body equal",
+			Expected: cmp.OriginBodySHA256,
+			Actual:   cmp.EdgeBodySHA256,
+			Passed:   cmp.BodyEqual,
+		},
+	}
+
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          target,
+		Status:       edge.Status,
+		StatusText:   edge.StatusText,
+		Duration:     edge.Duration.Milliseconds(),
+		Success:      checksPassed(checks),
+		Headers:      flattenHeaders(edge.Header),
+		Checks:       checks,
+		Origin:       &cmp,
+	}
+}
+
+// directProbe fetches target from the provider's origin address, keeping
+// the URL's scheme and path but dialing OriginAddr and presenting
+// OriginHost as Host header and TLS server name. Certificate verification
+// is skipped because origins sit behind the CDN with self-signed
+// certificates.
+func directProbe(ctx context.Context, provider config.ProviderConfig, target string, timeout time.Duration) (probeResponse, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return probeResponse{}, err
+	}
+	host := provider.OriginHost
+	if host == "" {
+		host = u.Hostname()
+	}
+
+	addr := provider.OriginAddr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		addr = net.JoinHostPort(addr, port)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			TLSClientConfig:   &tls.Config{ServerName: host, InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	return doProbe(ctx, client, http.MethodGet, target, map[string]string{"Host": host})
+}
+
+func compareResponses(edge, origin probeResponse) OriginComparison {
+	edgeSum := sha256.Sum256(edge.Body)
+	originSum := sha256.Sum256(origin.Body)
+	cmp := OriginComparison{
+		EdgeStatus:       edge.Status,
+		OriginStatus:     origin.Status,
+		EdgeMs:           edge.Duration.Milliseconds(),
+		OriginMs:         origin.Duration.Milliseconds(),
+		DeltaMs:          edge.Duration.Milliseconds() - origin.Duration.Milliseconds(),
+		BodyEqual:        edgeSum == originSum,
+		EdgeBodySHA256:   hex.EncodeToString(edgeSum[:]),
+		OriginBodySHA256: hex.EncodeToString(originSum[:]),
+	}
+
+	for key := range edge.Header {
+		if volatileHeaders[key] {
+			continue
+		}
+		if _, ok := origin.Header[key]; !ok {
+			cmp.AddedHeaders = append(cmp.AddedHeaders, key)
+		} else if strings.Join(edge.Header[key], ", ") != strings.Join(origin.Header[key], ", ") {
+			cmp.ChangedHeaders = append(cmp.ChangedHeaders, key)
+		}
+	}
+	for key := range origin.Header {
+		if volatileHeaders[key] {
+			continue
+		}
+		if _, ok := edge.Header[key]; !ok {
+			cmp.StrippedHeaders = append(cmp.StrippedHeaders, key)
+		}
+	}
+	sort.Strings(cmp.AddedHeaders)
+	sort.Strings(cmp.StrippedHeaders)
+	sort.Strings(cmp.ChangedHeaders)
+	return cmp
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+)
+
+func TestOriginComparisonDiffsHeadersAndBody(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "origin.example" {
+			t.Errorf("expected Host override, got %q", r.Host)
+		}
+		w.Header().Set("X-Origin-Server-Addr", "10.0.0.5")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_, _ = w.Write([]byte("probe"))
+	}))
+	defer origin.Close()
+
+	edge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		_, _ = w.Write([]byte("probe"))
+	}))
+	defer edge.Close()
+
+	cfg := config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"verge": {
+				ID:         "verge",
+				OriginURL:  edge.URL,
+				OriginAddr: strings.TrimPrefix(origin.URL, "http://"),
+				OriginHost: "origin.example",
+			},
+		},
+	}
+	res := NewRunner(cfg, nil).runOriginComparison(context.Background(), "/probe.txt", "verge")
+	if res.Origin == nil {
+		t.Fatalf("expected comparison, got error %q", res.Error)
+	}
+
+	cmp := res.Origin
+	if !cmp.BodyEqual || !res.Success {
+		t.Fatalf("expected equal bodies, got %+v", cmp)
+	}
+	if strings.Join(cmp.AddedHeaders, ",") != "X-Cache" {
+		t.Fatalf("unexpected added headers: %v", cmp.AddedHeaders)
+	}
+	if strings.Join(cmp.StrippedHeaders, ",") != "X-Origin-Server-Addr" {
+		t.Fatalf("unexpected stripped headers: %v", cmp.StrippedHeaders)
+	}
+	if strings.Join(cmp.ChangedHeaders, ",") != "Cache-Control" {
+		t.Fatalf("unexpected changed headers: %v", cmp.ChangedHeaders)
+	}
+}
@@ -9,31 +9,49 @@ import (
 	"time"
 
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/config"
+	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/openapi"
 	"github.com/mehrdad/project/training/cloud/Test-CDN/backend/internal/providers"
 )
 
 type Runner struct {
-	cfg      config.Config
-	registry *providers.Registry
-	client   *http.Client
+	cfg        config.Config
+	registry   *providers.Registry
+	client     *http.Client
+	ipv4Client *http.Client
+	ipv6Client *http.Client
+	raceClient *http.Client
 }
 
 func NewRunner(cfg config.Config, registry *providers.Registry) *Runner {
 	return &Runner{
-		cfg:      cfg,
-		registry: registry,
-		client:   &http.Client{Timeout: 30 * time.Second},
+		cfg:        cfg,
+		registry:   registry,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		ipv4Client: newFamilyClient("tcp4", 30*time.Second),
+		ipv6Client: newFamilyClient("tcp6", 30*time.Second),
+		raceClient: newRaceClient(30 * time.Second),
 	}
 }
 
 type RunRequest struct {
-	Rounds       int      `json:"rounds"`
-	DelaySeconds int      `json:"delay"`
-	Providers    []string `json:"providers"`
+	Rounds       int          `json:"rounds"`
+	DelaySeconds int          `json:"delay"`
+	Providers    []string     `json:"providers"`
+	Suites       []string     `json:"suites,omitempty"`
+	Burst        *BurstConfig `json:"burst,omitempty"`
+	Load         *LoadProfile `json:"load,omitempty"`
+	Fuzz         *FuzzConfig  `json:"fuzz,omitempty"`
+	Resolvers    []string     `json:"resolvers,omitempty"`
+	DualStack    bool         `json:"dualStack,omitempty"`
+	// Domain and Profile select a named domain and credential profile of
+	// every provider in the run.
+	Domain  string `json:"domain,omitempty"`
+	Profile string `json:"profile,omitempty"`
 }
 
 type RunResponse struct {
-	Results []Result `json:"results"`
+	Results        []Result        `json:"results"`
+	SecurityGrades []SecurityGrade `json:"securityGrades,omitempty"`
 }
 
 type Result struct {
@@ -50,20 +68,38 @@ type Result struct {
 	Error             string            `json:"error,omitempty"`
 	IsAPITest         bool              `json:"isApiTest,omitempty"`
 	APIResults        []APIResult       `json:"apiResults,omitempty"`
+	Checks            []Check           `json:"checks,omitempty"`
+	Hotlink           *HotlinkReport    `json:"hotlink,omitempty"`
+	Burst             *BurstReport      `json:"burst,omitempty"`
+	Load              *LoadReport       `json:"load,omitempty"`
+	Origin            *OriginComparison `json:"origin,omitempty"`
+	DNS               *DNSReport        `json:"dns,omitempty"`
+	DualStack         *DualStackReport  `json:"dualStack,omitempty"`
+	HeaderAudit       *HeaderAudit      `json:"headerAudit,omitempty"`
+	ErrorPage         *ErrorPageReport  `json:"errorPage,omitempty"`
+	Auth              *AuthReport       `json:"auth,omitempty"`
+	Fuzz              *FuzzReport       `json:"fuzz,omitempty"`
 }
 
 type APIResult struct {
-	ProviderID string      `json:"providerId"`
-	Success    bool        `json:"success"`
-	Status     int         `json:"status"`
-	Error      string      `json:"error,omitempty"`
-	Data       interface{} `json:"data,omitempty"`
+	ProviderID string                    `json:"providerId"`
+	Success    bool                      `json:"success"`
+	Status     int                       `json:"status"`
+	Attempts   int                       `json:"attempts,omitempty"`
+	QueueWait  int64                     `json:"queueWait,omitempty"`
+	Total      int                       `json:"total,omitempty"`
+	Pages      int                       `json:"pages,omitempty"`
+	Truncated  bool                      `json:"truncated,omitempty"`
+	Validation *openapi.ValidationReport `json:"validation,omitempty"`
+	Error      string                    `json:"error,omitempty"`
+	Data       interface{}               `json:"data,omitempty"`
 }
 
 type ProgressEvent struct {
-	Completed int     `json:"completed"`
-	Total     int     `json:"total"`
-	Result    *Result `json:"result,omitempty"`
+	Completed int           `json:"completed"`
+	Total     int           `json:"total"`
+	Result    *Result       `json:"result,omitempty"`
+	Load      *LoadInterval `json:"load,omitempty"`
 }
 
 func (r *Runner) Run(ctx context.Context, req RunRequest) (RunResponse, error) {
@@ -86,11 +122,33 @@ func (r *Runner) run(ctx context.Context, req RunRequest, handler func(ProgressE
 	if len(providerIDs) == 0 {
 		return RunResponse{}, errors.New("no providers configured")
 	}
+	if req.Domain != "" || req.Profile != "" {
+		cfg, err := r.cfg.Scoped(providerIDs, req.Domain, req.Profile)
+		if err != nil {
+			return RunResponse{}, err
+		}
+		scoped := *r
+		scoped.cfg = cfg
+		r = &scoped
+		ctx = providers.WithScope(ctx, providers.Scope{Domain: req.Domain, Profile: req.Profile})
+	}
+
+	if req.Load != nil {
+		return r.runLoad(ctx, *req.Load, providerIDs, handler)
+	}
+	if req.Fuzz != nil {
+		return r.runFuzz(ctx, *req.Fuzz, providerIDs, handler)
+	}
+
+	suiteCases, err := r.resolveSuiteCases(req, providerIDs)
+	if err != nil {
+		return RunResponse{}, err
+	}
 
 	frontendEndpoints := FrontendEndpoints()
 	apiEndpoints := APIEndpoints()
 	delay := time.Duration(req.DelaySeconds) * time.Second
-	totalPerRound := len(frontendEndpoints)*len(providerIDs) + len(apiEndpoints)
+	totalPerRound := len(frontendEndpoints)*len(providerIDs) + len(apiEndpoints) + len(suiteCases)
 	totalTests := totalPerRound * req.Rounds
 	results := make([]Result, 0, totalTests)
 	completed := 0
@@ -117,6 +175,9 @@ func (r *Runner) run(ctx context.Context, req RunRequest, handler func(ProgressE
 
 			for _, providerID := range providerIDs {
 				result := r.runHTTPTest(ctx, endpoint, providerID)
+				if req.DualStack && result.URL != "" {
+					result.DualStack = r.runDualStack(ctx, result.URL)
+				}
 				results = append(results, result)
 				emitProgress(result)
 			}
@@ -134,6 +195,18 @@ func (r *Runner) run(ctx context.Context, req RunRequest, handler func(ProgressE
 			emitProgress(result)
 		}
 
+		for _, run := range suiteCases {
+			select {
+			case <-ctx.Done():
+				return RunResponse{}, ctx.Err()
+			default:
+			}
+
+			result := run(ctx)
+			results = append(results, result)
+			emitProgress(result)
+		}
+
 		if round < req.Rounds-1 && delay > 0 {
 			select {
 			case <-ctx.Done():
@@ -143,7 +216,7 @@ func (r *Runner) run(ctx context.Context, req RunRequest, handler func(ProgressE
 		}
 	}
 
-	return RunResponse{Results: results}, nil
+	return RunResponse{Results: results, SecurityGrades: securityGrades(results)}, nil
 }
 
 func (r *Runner) resolveProviders(requested []string) []string {
@@ -217,6 +290,7 @@ func (r *Runner) runHTTPTest(ctx context.Context, endpoint Endpoint, providerID
 	defer resp.Body.Close()
 
 	headers := flattenHeaders(resp.Header)
+	audit := auditHeaders(resp.Header)
 	duration := time.Since(start).Milliseconds()
 	isSecurityTest := endpoint.Category == "security" || strings.Contains(endpoint.Path, "/security/")
 	blockedBySecurity := isSecurityTest && resp.StatusCode == http.StatusForbidden
@@ -233,6 +307,7 @@ func (r *Runner) runHTTPTest(ctx context.Context, endpoint Endpoint, providerID
 		Success:           success,
 		BlockedBySecurity: blockedBySecurity,
 		Headers:           headers,
+		HeaderAudit:       &audit,
 	}
 }
 
@@ -240,23 +315,32 @@ func (r *Runner) runAPITest(ctx context.Context, endpoint Endpoint, providerIDs
 	apiResults := make([]APIResult, 0, len(providerIDs))
 	start := time.Now()
 
+	resource := strings.TrimPrefix(endpoint.Path, "/api-test")
 	for _, providerID := range providerIDs {
-		body, status, err := r.registry.Call(providerID, strings.TrimPrefix(endpoint.Path, "/api-test"))
+		if providers.IsListResource(resource) {
+			apiResults = append(apiResults, r.runAPIList(ctx, providerID, resource))
+			continue
+		}
+
+		resp, err := r.registry.Send(ctx, providerID, http.MethodGet, resource, nil)
 		apiResult := APIResult{
 			ProviderID: providerID,
-			Status:     status,
+			Status:     resp.Status,
+			Attempts:   resp.Attempts,
+			QueueWait:  resp.QueueWait.Milliseconds(),
 		}
 
 		if err != nil {
 			apiResult.Success = false
 			apiResult.Error = err.Error()
 		} else {
-			apiResult.Success = status >= 200 && status < 300
+			apiResult.Validation = r.registry.ValidateResponse(ctx, providerID, http.MethodGet, resource, resp.Status, resp.Body)
+			apiResult.Success = resp.Status >= 200 && resp.Status < 300 && schemaValid(apiResult.Validation)
 			var data interface{}
-			if err := json.Unmarshal(body, &data); err == nil {
+			if err := json.Unmarshal(resp.Body, &data); err == nil {
 				apiResult.Data = data
 			} else {
-				apiResult.Data = string(body)
+				apiResult.Data = string(resp.Body)
 			}
 		}
 
@@ -282,6 +366,33 @@ func (r *Runner) runAPITest(ctx context.Context, endpoint Endpoint, providerIDs
 	}
 }
 
+// runAPIList walks every page of a list resource and reports the provider's
+// total alongside the number of pages fetched.
+func (r *Runner) runAPIList(ctx context.Context, providerID, resource string) APIResult {
+	list, err := r.registry.CallAll(ctx, providerID, resource, providers.DefaultListLimit)
+	result := APIResult{
+		ProviderID: providerID,
+		Status:     list.Status,
+		Attempts:   list.Attempts,
+		QueueWait:  list.QueueWait.Milliseconds(),
+		Total:      list.Total,
+		Pages:      list.Pages,
+		Truncated:  list.Truncated,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Validation = r.registry.ValidateResponse(ctx, providerID, http.MethodGet, resource, list.Status, list.LastPage)
+	result.Success = list.Status >= 200 && list.Status < 300 && schemaValid(result.Validation)
+	result.Data = map[string]interface{}{"data": list.Items}
+	return result
+}
+
+func schemaValid(report *openapi.ValidationReport) bool {
+	return report == nil || report.Valid
+}
+
 func flattenHeaders(h http.Header) map[string]string {
 	out := make(map[string]string, len(h))
 	for key, values := range h {
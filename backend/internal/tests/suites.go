@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Suite is an optional group of probes enabled per run through
+// RunRequest.Suites, executed after the default endpoints of each round.
+type Suite struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	cases func(r *Runner, req RunRequest, providerIDs []string) []testCase
+}
+
+type testCase func(ctx context.Context) Result
+
+// Check is a single named assertion made by a suite probe.
+type Check struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Passed   bool   `json:"passed"`
+}
+
+var suites = []Suite{
+	{ID: "cors", Name: "CORS Preflight & Exposed Headers", cases: corsCases},
+	{ID: "hotlink", Name: "Hotlink Protection", cases: hotlinkCases},
+	{ID: "ratelimit", Name: "Rate-Limit Burst", cases: burstCases},
+	{ID: "origin", Name: "Origin vs Edge", cases: originCases},
+	{ID: "dns", Name: "DNS Resolution & Edge Mapping", cases: dnsCases},
+	{ID: "headers", Name: "Security Header Audit", cases: headerAuditCases},
+	{ID: "errors", Name: "Custom Error Pages", cases: errorPageCases},
+	{ID: "auth", Name: "API Authentication", cases: authSuiteCases},
+}
+
+func Suites() []Suite {
+	return append([]Suite{}, suites...)
+}
+
+func suiteByID(id string) (Suite, bool) {
+	key := strings.ToLower(strings.TrimSpace(id))
+	for _, s := range suites {
+		if s.ID == key {
+			return s, true
+		}
+	}
+	return Suite{}, false
+}
+
+func (r *Runner) resolveSuiteCases(req RunRequest, providerIDs []string) ([]testCase, error) {
+	var out []testCase
+	for _, id := range req.Suites {
+		s, ok := suiteByID(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown suite: %s", id)
+		}
+		out = append(out, s.cases(r, req, providerIDs)...)
+	}
+	return out, nil
+}
+
+type probeResponse struct {
+	Status     int
+	StatusText string
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+}
+
+// probe issues a single request against a provider edge and reads the full
+// body so the duration covers the complete transfer.
+func (r *Runner) probe(ctx context.Context, method, url string, headers map[string]string) (probeResponse, error) {
+	return doProbe(ctx, r.client, method, url, headers)
+}
+
+func doProbe(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (probeResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return probeResponse{}, err
+	}
+	for k, v := range headers {
+		if v == "" {
+			continue
+		}
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return probeResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return probeResponse{}, err
+	}
+
+	return probeResponse{
+		Status:     resp.StatusCode,
+		StatusText: resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+		Duration:   time.Since(start),
+	}, nil
+}
+
+func checksPassed(checks []Check) bool {
+	for _, c := range checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return len(checks) > 0
+}
+
+func errorResult(id, name, providerID, url string, err error) Result {
+	return Result{
+		EndpointID:   id,
+		EndpointName: name,
+		ProviderID:   providerID,
+		URL:          url,
+		Status:       "ERROR",
+		Error:        err.Error(),
+		Success:      false,
+	}
+}